@@ -0,0 +1,134 @@
+package contactapp
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/export"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+// etag is the entity tag of a version of a contact
+func etag(c models.Contact) string {
+	return strconv.Quote(strconv.Itoa(c.Version))
+}
+
+// ifMatch reports whether the If-Match header of r, if any, is * or names
+// the version of c. weak tags, e.g. W/"3", name the version as well.
+func ifMatch(r *http.Request, c models.Contact) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	for tag := range strings.SplitSeq(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeContactJSON(w http.ResponseWriter, c models.Contact) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(c))
+	if err := json.NewEncoder(w).Encode(export.FromModel(c)); err != nil {
+		log.Println(err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, code int, errors any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{"errors": errors})
+}
+
+// /api/contacts/{id} is a contact in the json export format, with its
+// version as the ETag
+func (s *Server) getContactJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	contact, err := s.store.GetContact(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeContactJSON(w, contact)
+}
+
+// /api/contacts/{id} replaces a contact. with If-Match it only does so if
+// the contact is still at that version, without it the last write wins.
+func (s *Server) putContactJSON(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	var body export.Contact
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	contact, err := body.ToModel()
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, map[string]string{"birthday": "must be yyyy-mm-dd"})
+		return
+	}
+	contact.ID = id
+	form := views.ContactFormFromContact(&contact)
+	form.Tags = models.NormalizeTags(form.Tags)
+	form.Region = s.phoneRegion
+	form.Fields = s.fields.Fields()
+	if !form.Valid() {
+		writeJSONError(w, http.StatusUnprocessableEntity, form.Errors)
+		return
+	}
+	before, err := s.store.GetContact(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !ifMatch(r, before) {
+		http.Error(w, ErrEditConflict.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	// saved as the edit form would save it, the version is checked above
+	after := *form.ToContact()
+	after.Version = before.Version
+	after.Photo = before.Photo
+	if err := s.store.EditContact(after); err != nil {
+		switch err {
+		case ErrDuplicateEmail:
+			writeJSONError(w, http.StatusConflict, map[string]string{views.ContactFormEmail: err.Error()})
+		case ErrEditConflict:
+			// edited between the check above and now
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		case ErrNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	// the revision gets the version and time the store saved
+	current, err := s.store.GetContact(id)
+	if err != nil {
+		// deleted since, the edit still happened
+		current = after
+	}
+	s.recordChange(r, audit.ActionEdit, before, current)
+	s.recordRevision(r, before, current, 0)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeContactJSON(w, current)
+}
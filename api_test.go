@@ -0,0 +1,155 @@
+package contactapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/rezbow/contact-app/export"
+)
+
+func TestEditConflict(t *testing.T) {
+	store := NewinMemoryStore()
+	server := NewContactServer(store)
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	edit := func(form string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/contacts/1/edit", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+
+	contact, _ := store.GetContact(1)
+	theirs, mine := contact, contact
+	theirs.FirstName = "Jackson"
+	mine.LastName = "Jacksonville"
+
+	assertRedirect(t, edit(contactToForm(theirs)), "/contacts/1")
+	res := edit(contactToForm(mine))
+	assertCode(t, res.Code, http.StatusConflict)
+	body := res.Body.String()
+	for _, want := range []string{"Their version (1)", "<ins>Jacksonville</ins>", `name="version" value="1"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in the conflict view", want)
+		}
+	}
+	if current, _ := store.GetContact(1); current.FirstName != "Jackson" || current.LastName != contact.LastName {
+		t.Errorf("stale edit overwrote %+v", current)
+	}
+
+	// saving from the conflict view goes over their version
+	mine.Version = 1
+	assertRedirect(t, edit(contactToForm(mine)), "/contacts/1")
+	if current, _ := store.GetContact(1); current.LastName != "Jacksonville" || current.Version != 2 {
+		t.Errorf("got %+v, wanted my edit at version 2", current)
+	}
+}
+
+func TestContactAPI(t *testing.T) {
+	store := NewinMemoryStore()
+	server := NewContactServer(store)
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	put := func(c export.Contact, ifMatch string) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(c)
+		req, _ := http.NewRequest(http.MethodPut, "/api/contacts/1", strings.NewReader(string(data)))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		return serve(req)
+	}
+
+	res := serve(newGetRequest("/api/contacts/1"))
+	assertCode(t, res.Code, http.StatusOK)
+	etag := res.Header().Get("ETag")
+	var contact export.Contact
+	if err := json.NewDecoder(res.Body).Decode(&contact); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("put with the current etag", func(t *testing.T) {
+		contact.Company = "Acme"
+		res := put(contact, etag)
+		assertCode(t, res.Code, http.StatusOK)
+		if got := res.Header().Get("ETag"); got == etag || got == "" {
+			t.Errorf("expected a new etag, got %q", got)
+		}
+		if current, _ := store.GetContact(1); current.Company != "Acme" {
+			t.Errorf("got company %q, wanted Acme", current.Company)
+		}
+	})
+
+	t.Run("put with a stale etag", func(t *testing.T) {
+		contact.Company = "Initech"
+		assertCode(t, put(contact, etag).Code, http.StatusPreconditionFailed)
+		if current, _ := store.GetContact(1); current.Company != "Acme" {
+			t.Errorf("stale put changed the company to %q", current.Company)
+		}
+	})
+
+	t.Run("put with a weak etag", func(t *testing.T) {
+		current, _ := store.GetContact(1)
+		res := put(contact, fmt.Sprintf(`W/"%d"`, current.Version))
+		assertCode(t, res.Code, http.StatusOK)
+		saved, _ := store.GetContact(1)
+		revisions := server.revisions.Revisions(1)
+		if last := revisions[len(revisions)-1].Contact; last.Version != saved.Version || !last.UpdatedAt.Equal(saved.UpdatedAt) {
+			t.Errorf("revision has version %d, wanted the saved version %d", last.Version, saved.Version)
+		}
+	})
+
+	t.Run("put without if-match or with any", func(t *testing.T) {
+		assertCode(t, put(contact, "").Code, http.StatusOK)
+		assertCode(t, put(contact, "*").Code, http.StatusOK)
+	})
+
+	t.Run("put is normalized like the edit form", func(t *testing.T) {
+		contact.Tags = []string{"Customer ", "VIP"}
+		contact.Custom = map[string]string{"unknown": "value"}
+		assertCode(t, put(contact, "").Code, http.StatusOK)
+		current, _ := store.GetContact(1)
+		if !slices.Equal(current.Tags, []string{"customer", "vip"}) || current.Custom != nil {
+			t.Errorf("got tags %q and custom values %v", current.Tags, current.Custom)
+		}
+		res := serve(newGetRequest("/contacts?tag=customer"))
+		if !strings.Contains(res.Body.String(), `href="/contacts/1"`) {
+			t.Errorf("expected the contact to be found by its tag")
+		}
+	})
+
+	t.Run("invalid contacts are rejected", func(t *testing.T) {
+		contact.FirstName = ""
+		res := put(contact, "")
+		assertCode(t, res.Code, http.StatusUnprocessableEntity)
+		var got struct{ Errors map[string]string }
+		json.NewDecoder(res.Body).Decode(&got)
+		if len(got.Errors) == 0 {
+			t.Errorf("expected the errors of the contact")
+		}
+	})
+
+	t.Run("put needs the csrf token", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPut, "/api/contacts/1", strings.NewReader("{}"))
+		req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: session.ID})
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, req)
+		assertCode(t, res.Code, http.StatusForbidden)
+	})
+
+	assertCode(t, serve(newGetRequest("/api/contacts/99")).Code, http.StatusNotFound)
+}
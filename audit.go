@@ -0,0 +1,74 @@
+package contactapp
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+const (
+	anonymousActor = "anonymous"
+	// background jobs like the trash purger
+	systemActor = "system"
+)
+
+// who is making the request, as recorded in the audit log
+func actor(r *http.Request) string {
+	if user := views.CurrentUser(r.Context()); user != nil {
+		if user.Email != "" {
+			return user.Email
+		}
+		return "user:" + strconv.Itoa(user.ID)
+	}
+	return anonymousActor
+}
+
+func (s *Server) recordChange(r *http.Request, action audit.Action, before, after models.Contact) {
+	s.recordEvent(actor(r), requestIDFromContext(r.Context()), action, before, after)
+}
+
+func (s *Server) recordEvent(actor, requestID string, action audit.Action, before, after models.Contact) {
+	contactID := after.ID
+	if contactID == 0 {
+		contactID = before.ID
+	}
+	err := s.audit.Record(audit.Event{
+		Actor:     actor,
+		Action:    action,
+		ContactID: contactID,
+		Changes:   models.DiffContacts(before, after),
+		RequestID: requestID,
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// /audit?contact=1&actor=me@example.com&from=2026-01-01&to=2026-01-31
+func (s *Server) getAuditLog(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	model := views.AuditViewModel{
+		Contact: q.Get("contact"),
+		Actor:   q.Get("actor"),
+		From:    q.Get("from"),
+		To:      q.Get("to"),
+	}
+	filter := audit.Filter{Actor: model.Actor}
+	if id, err := strconv.Atoi(model.Contact); err == nil {
+		filter.ContactID = id
+	}
+	if from, err := time.Parse(time.DateOnly, model.From); err == nil {
+		filter.From = from
+	}
+	// the date range is inclusive of the whole "to" day
+	if to, err := time.Parse(time.DateOnly, model.To); err == nil {
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	model.Events = s.audit.Events(filter)
+	render(w, r.Context(), views.AuditLog(model))
+}
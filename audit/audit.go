@@ -0,0 +1,138 @@
+// Package audit keeps an append-only record of changes to contacts.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+type Action string
+
+const (
+	ActionCreate     Action = "create"
+	ActionEdit       Action = "edit"
+	ActionDelete     Action = "delete"
+	ActionBulkDelete Action = "bulk delete"
+	ActionRevert     Action = "revert"
+	ActionRestore    Action = "restore"
+	ActionPurge      Action = "purge"
+	ActionMerge      Action = "merge"
+)
+
+type Event struct {
+	ID        int                  `json:"id"`
+	Time      time.Time            `json:"time"`
+	Actor     string               `json:"actor"`
+	Action    Action               `json:"action"`
+	ContactID int                  `json:"contact_id"`
+	Changes   []models.FieldChange `json:"changes,omitempty"`
+	RequestID string               `json:"request_id"`
+}
+
+// zero values match everything, To is exclusive
+type Filter struct {
+	ContactID int
+	Actor     string
+	From, To  time.Time
+}
+
+func (f Filter) Match(e Event) bool {
+	switch {
+	case f.ContactID != 0 && e.ContactID != f.ContactID:
+		return false
+	case f.Actor != "" && e.Actor != f.Actor:
+		return false
+	case !f.From.IsZero() && e.Time.Before(f.From):
+		return false
+	case !f.To.IsZero() && !e.Time.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// Log writes every event as a json line to w and keeps them in memory for
+// querying.
+type Log struct {
+	mu     sync.RWMutex
+	w      io.Writer
+	events []Event
+	now    func() time.Time
+}
+
+func New(w io.Writer) *Log {
+	return &Log{w: w, now: time.Now}
+}
+
+// Open loads the events already in the file at path and appends new ones to it
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	l := New(f)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("reading audit log: line %d: %w", len(l.events)+1, err)
+		}
+		l.events = append(l.events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading audit log: %w", err)
+	}
+	return l, nil
+}
+
+// Record assigns the event an id and timestamp and persists it before
+// returning.
+func (l *Log) Record(e Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e.ID = len(l.events) + 1
+	e.Time = l.now().UTC()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := l.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("writing audit event: %w", err)
+	}
+	if f, ok := l.w.(*os.File); ok {
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("syncing audit log: %w", err)
+		}
+	}
+	l.events = append(l.events, e)
+	return nil
+}
+
+// Events returns the matching events, newest first
+func (l *Log) Events(f Filter) []Event {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var events []Event
+	for i := len(l.events) - 1; i >= 0; i-- {
+		if f.Match(l.events[i]) {
+			events = append(events, l.events[i])
+		}
+	}
+	return events
+}
+
+func (l *Log) Close() error {
+	if c, ok := l.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
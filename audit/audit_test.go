@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestLog(t *testing.T) {
+	t.Run("events survive reopening the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		l, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Record(Event{Actor: "reza", Action: ActionCreate, ContactID: 1, RequestID: "req-1"})
+		l.Record(Event{Actor: "reza", Action: ActionEdit, ContactID: 1, Changes: []models.FieldChange{
+			{Field: "email", Before: "a@b.com", After: "c@d.com"},
+		}})
+		l.Close()
+
+		l, err = Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		events := l.Events(Filter{})
+		if len(events) != 2 {
+			t.Fatalf("got %d events after reopening, wanted 2", len(events))
+		}
+		if events[0].Action != ActionEdit || events[0].Changes[0].After != "c@d.com" {
+			t.Errorf("got %+v as newest event", events[0])
+		}
+		if events[1].RequestID != "req-1" {
+			t.Errorf("got request id %q, wanted %q", events[1].RequestID, "req-1")
+		}
+
+		l.Record(Event{Actor: "john", Action: ActionDelete, ContactID: 1})
+		if got := l.Events(Filter{})[0].ID; got != 3 {
+			t.Errorf("got id %d for appended event, wanted 3", got)
+		}
+	})
+
+	t.Run("filter by contact, actor and date range", func(t *testing.T) {
+		l := New(&bytes.Buffer{})
+		day := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		l.now = func() time.Time { return day }
+		l.Record(Event{Actor: "reza", ContactID: 1})
+		day = day.AddDate(0, 0, 1)
+		l.Record(Event{Actor: "john", ContactID: 1})
+		day = day.AddDate(0, 0, 1)
+		l.Record(Event{Actor: "reza", ContactID: 2})
+
+		cases := []struct {
+			name   string
+			filter Filter
+			want   []int
+		}{
+			{"everything", Filter{}, []int{3, 2, 1}},
+			{"by contact", Filter{ContactID: 1}, []int{2, 1}},
+			{"by actor", Filter{Actor: "reza"}, []int{3, 1}},
+			{"from", Filter{From: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)}, []int{3, 2}},
+			{"to is exclusive", Filter{To: time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)}, []int{1}},
+			{"combined", Filter{ContactID: 1, Actor: "reza", From: day.AddDate(0, 0, -3)}, []int{1}},
+		}
+		for _, c := range cases {
+			var got []int
+			for _, e := range l.Events(c.filter) {
+				got = append(got, e.ID)
+			}
+			if len(got) != len(c.want) {
+				t.Errorf("%s: got events %v, wanted %v", c.name, got, c.want)
+				continue
+			}
+			for i := range got {
+				if got[i] != c.want[i] {
+					t.Errorf("%s: got events %v, wanted %v", c.name, got, c.want)
+					break
+				}
+			}
+		}
+	})
+}
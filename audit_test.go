@@ -0,0 +1,135 @@
+package contactapp
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestAuditLog(t *testing.T) {
+	store := NewinMemoryStore()
+	store.contacts = store.contacts[:2]
+	store.idSeq = 2
+	users := NewInMemoryUserStore()
+	user, _ := users.AddUser(models.User{Email: "auditor@example.com"})
+	auditLog := audit.New(&bytes.Buffer{})
+	server := NewContactServer(store, WithUserStore(users), WithAuditLog(auditLog))
+	session := server.sessions.New()
+	session.UserID = user.ID
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		req.Header.Set(requestIDHeader, "req-"+req.Method)
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	latest := func() audit.Event {
+		t.Helper()
+		events := auditLog.Events(audit.Filter{})
+		if len(events) == 0 {
+			t.Fatal("no audit events recorded")
+		}
+		return events[0]
+	}
+
+	t.Run("add contact", func(t *testing.T) {
+		serve(newContactRequest(models.Contact{FirstName: "Reza", LastName: "B", Phones: phones("(415) 555-0101"), Emails: emails("rez@gmail.com")}))
+		event := latest()
+		if event.Action != audit.ActionCreate || event.ContactID != 3 || event.Actor != user.Email {
+			t.Errorf("got event %+v", event)
+		}
+		if event.RequestID != "req-POST" {
+			t.Errorf("got request id %q, wanted %q", event.RequestID, "req-POST")
+		}
+		if len(event.Changes) != 4 {
+			t.Errorf("got %d changes for a new contact, wanted all 4 fields", len(event.Changes))
+		}
+	})
+
+	t.Run("edit contact records only changed fields", func(t *testing.T) {
+		contact, _ := store.GetContact(1)
+		before := contact.PrimaryEmail()
+		contact.Emails = emails("jack@new.com")
+		serve(editContactRequest(contact))
+		event := latest()
+		want := []models.FieldChange{{Field: "emails", Before: "home: " + before, After: "home: jack@new.com"}}
+		if event.Action != audit.ActionEdit || event.ContactID != 1 || fmt.Sprint(event.Changes) != fmt.Sprint(want) {
+			t.Errorf("got event %+v, wanted changes %v", event, want)
+		}
+	})
+
+	t.Run("failed edit isn't recorded", func(t *testing.T) {
+		count := len(auditLog.Events(audit.Filter{}))
+		contact, _ := store.GetContact(1)
+		contact.Emails = emails("rez@gmail.com")
+		serve(editContactRequest(contact))
+		if got := len(auditLog.Events(audit.Filter{})); got != count {
+			t.Errorf("got %d events after failed edit, wanted %d", got, count)
+		}
+	})
+
+	t.Run("delete contact", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/contacts/3", nil)
+		serve(req)
+		event := latest()
+		if event.Action != audit.ActionDelete || event.ContactID != 3 || event.Changes[0].After != "" {
+			t.Errorf("got event %+v", event)
+		}
+	})
+
+	t.Run("bulk delete records every contact", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/contacts?selected_id=1&selected_id=2&selected_id=99", nil)
+		serve(req)
+		events := auditLog.Events(audit.Filter{})
+		if events[0].Action != audit.ActionBulkDelete || events[1].Action != audit.ActionBulkDelete {
+			t.Fatalf("got %v and %v, wanted two bulk deletes", events[0].Action, events[1].Action)
+		}
+		if events[0].ContactID != 2 || events[1].ContactID != 1 {
+			t.Errorf("got contacts %d and %d, wanted 2 and 1", events[0].ContactID, events[1].ContactID)
+		}
+	})
+
+	t.Run("audit page filters by contact and actor", func(t *testing.T) {
+		res := serve(newGetRequest("/audit?contact=1&actor=" + user.Email))
+		assertCode(t, res.Code, http.StatusOK)
+		body := res.Body.String()
+		if !strings.Contains(body, "jack@new.com") {
+			t.Errorf("expected edit of contact 1 in the audit page")
+		}
+		if strings.Contains(body, "rez@gmail.com") {
+			t.Errorf("didn't expect events of contact 3 in the audit page")
+		}
+
+		res = serve(newGetRequest("/audit?actor=someone-else"))
+		if strings.Contains(res.Body.String(), "<td>") {
+			t.Errorf("expected no events for unknown actor")
+		}
+	})
+
+	t.Run("audit page filters by date range", func(t *testing.T) {
+		res := serve(newGetRequest("/audit?from=2000-01-01&to=2000-01-02"))
+		if strings.Contains(res.Body.String(), "<td>") {
+			t.Errorf("expected no events in 2000")
+		}
+	})
+
+	t.Run("anonymous changes are turned away", func(t *testing.T) {
+		before := len(auditLog.Events(audit.Filter{}))
+		anon := server.sessions.New()
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(newContactRequest(models.Contact{FirstName: "A", LastName: "B", Phones: phones("(415) 555-0101"), Emails: emails("anon@x.com")}), anon))
+		assertRedirect(t, res, "/login")
+		if got := len(auditLog.Events(audit.Filter{})); got != before {
+			t.Errorf("got %d events, wanted %d", got, before)
+		}
+		if res.Header().Get(requestIDHeader) == "" {
+			t.Errorf("expected a generated request id in the response")
+		}
+	})
+}
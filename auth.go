@@ -0,0 +1,149 @@
+package contactapp
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/oidc"
+	"github.com/rezbow/contact-app/views"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+)
+
+func (s *Server) loginPage(w http.ResponseWriter, r *http.Request) {
+	render(w, r.Context(), views.Login(views.LoginViewModel{SSOEnabled: s.oidc != nil}))
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	email := r.PostFormValue(views.LoginFormEmail)
+	password := r.PostFormValue(views.LoginFormPassword)
+	user, err := s.users.GetUserByEmail(email)
+	if err != nil || user.PasswordHash == "" || !CheckPassword(user.PasswordHash, password) {
+		w.WriteHeader(http.StatusUnauthorized)
+		render(w, r.Context(), views.Login(views.LoginViewModel{
+			Email:      email,
+			Error:      ErrInvalidCredentials.Error(),
+			SSOEnabled: s.oidc != nil,
+		}))
+		return
+	}
+	s.sessions.Renew(w, sessionFromContext(r.Context()), user.ID)
+	redirect(w, r, "/contacts")
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	s.sessions.Renew(w, sessionFromContext(r.Context()), 0)
+	redirect(w, r, "/login")
+}
+
+// requireUser sends visitors who aren't logged in to the login page. the
+// pages of logging in, static files and the calendar feed, which has a token
+// of its own, are open to them.
+func (s *Server) requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if views.CurrentUser(r.Context()) == nil && !isPublic(r) {
+			redirect(w, r, "/login")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isPublic(r *http.Request) bool {
+	path := r.URL.Path
+	switch {
+	case path == "/login",
+		strings.HasPrefix(path, "/auth/oidc/"),
+		strings.HasPrefix(path, "/static/"),
+		r.Method == http.MethodGet && strings.HasPrefix(path, "/calendar/"):
+		return true
+	}
+	return false
+}
+
+// /auth/oidc/login
+func (s *Server) oidcLogin(w http.ResponseWriter, r *http.Request) {
+	if s.oidc == nil {
+		http.NotFound(w, r)
+		return
+	}
+	session := sessionFromContext(r.Context())
+	session.OIDCState = oidc.NewState()
+	session.OIDCNonce = oidc.NewState()
+	session.OIDCVerifier = oidc.NewCodeVerifier()
+	http.Redirect(w, r, s.oidc.AuthCodeURL(session.OIDCState, session.OIDCNonce, session.OIDCVerifier), http.StatusFound)
+}
+
+// /auth/oidc/callback
+func (s *Server) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	if s.oidc == nil {
+		http.NotFound(w, r)
+		return
+	}
+	session := sessionFromContext(r.Context())
+	if session == nil {
+		http.Error(w, "invalid login state", http.StatusBadRequest)
+		return
+	}
+	state, nonce, verifier := session.OIDCState, session.OIDCNonce, session.OIDCVerifier
+	session.OIDCState, session.OIDCNonce, session.OIDCVerifier = "", "", ""
+
+	q := r.URL.Query()
+	if state == "" || subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(state)) != 1 {
+		http.Error(w, "invalid login state", http.StatusBadRequest)
+		return
+	}
+	if e := q.Get("error"); e != "" {
+		log.Printf("sso login failed: %s %s", e, q.Get("error_description"))
+		http.Error(w, "sso login failed", http.StatusUnauthorized)
+		return
+	}
+	raw, err := s.oidc.Exchange(r.Context(), q.Get("code"), verifier)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "sso login failed", http.StatusUnauthorized)
+		return
+	}
+	token, err := s.oidc.Verify(r.Context(), raw, nonce)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "sso login failed", http.StatusUnauthorized)
+		return
+	}
+	user, err := s.provisionOIDCUser(token)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "couldn't provision account", http.StatusConflict)
+		return
+	}
+	s.sessions.Renew(w, session, user.ID)
+	redirect(w, r, "/contacts")
+}
+
+// finds the account of an sso user, creating it on first login. an existing
+// local account is only linked when the provider has verified the email.
+func (s *Server) provisionOIDCUser(token *oidc.IDToken) (models.User, error) {
+	if user, err := s.users.GetUserBySubject(token.Issuer, token.Subject); err == nil {
+		return user, nil
+	}
+	if token.EmailVerified && token.Email != "" {
+		if user, err := s.users.GetUserByEmail(token.Email); err == nil {
+			user.OIDCIssuer = token.Issuer
+			user.OIDCSubject = token.Subject
+			return user, s.users.EditUser(user)
+		}
+	}
+	return s.users.AddUser(models.User{
+		Email:       strings.TrimSpace(token.Email),
+		Name:        token.Name,
+		OIDCIssuer:  token.Issuer,
+		OIDCSubject: token.Subject,
+	})
+}
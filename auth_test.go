@@ -0,0 +1,290 @@
+package contactapp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/oidc"
+	"github.com/rezbow/contact-app/oidc/oidctest"
+	"github.com/rezbow/contact-app/views"
+)
+
+// starts the app behind a real listener so the browser-like client can
+// bounce between it and the fake provider
+func newSSOApp(t *testing.T, fake *oidctest.Provider, users UserStore) (*Server, *httptest.Server) {
+	t.Helper()
+	app := httptest.NewUnstartedServer(nil)
+	app.Start()
+	t.Cleanup(app.Close)
+	provider, err := oidc.Discover(context.Background(), fake.Config(app.URL+"/auth/oidc/callback"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewContactServer(&StubContactStore{}, WithUserStore(users), WithOIDC(provider))
+	app.Config.Handler = server
+	return server, app
+}
+
+func newBrowser() *http.Client {
+	jar, _ := cookiejar.New(nil)
+	return &http.Client{Jar: jar}
+}
+
+func sessionOf(t *testing.T, server *Server, client *http.Client, app *httptest.Server) *Session {
+	t.Helper()
+	u, _ := url.Parse(app.URL)
+	for _, cookie := range client.Jar.Cookies(u) {
+		if cookie.Name == sessionCookieName {
+			return server.sessions.Get(cookie.Value)
+		}
+	}
+	return nil
+}
+
+func TestOIDCLogin(t *testing.T) {
+	fake := oidctest.NewProvider()
+	defer fake.Close()
+
+	t.Run("first login provisions a user and logs them in", func(t *testing.T) {
+		users := NewInMemoryUserStore()
+		server, app := newSSOApp(t, fake, users)
+		browser := newBrowser()
+
+		res, err := browser.Get(app.URL + "/auth/oidc/login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		assertCode(t, res.StatusCode, http.StatusOK)
+		if res.Request.URL.Path != "/contacts" {
+			t.Errorf("ended up at %q, wanted /contacts", res.Request.URL.Path)
+		}
+		if !strings.Contains(string(body), "Signed in as "+fake.User.Name) {
+			t.Errorf("expected page to show the logged in user")
+		}
+
+		user, err := users.GetUserBySubject(fake.URL, fake.User.Subject)
+		if err != nil {
+			t.Fatalf("user wasn't provisioned: %v", err)
+		}
+		if user.Email != fake.User.Email || user.Name != fake.User.Name {
+			t.Errorf("got user %+v, wanted claims of %+v", user, fake.User)
+		}
+		if session := sessionOf(t, server, browser, app); session == nil || session.UserID != user.ID {
+			t.Errorf("session isn't logged in as user %d", user.ID)
+		}
+
+		// logging in again reuses the account
+		res, err = newBrowser().Get(app.URL + "/auth/oidc/login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if len(users.users) != 1 {
+			t.Errorf("got %d users after second login, wanted 1", len(users.users))
+		}
+	})
+
+	t.Run("verified email links an existing local account", func(t *testing.T) {
+		users := NewInMemoryUserStore()
+		local, _ := users.AddUser(models.User{Email: fake.User.Email, PasswordHash: "x"})
+		server, app := newSSOApp(t, fake, users)
+		browser := newBrowser()
+
+		res, err := browser.Get(app.URL + "/auth/oidc/login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		user, _ := users.GetUser(local.ID)
+		if user.OIDCSubject != fake.User.Subject {
+			t.Errorf("local account wasn't linked, got %+v", user)
+		}
+		if session := sessionOf(t, server, browser, app); session.UserID != local.ID {
+			t.Errorf("got session user %d, wanted %d", session.UserID, local.ID)
+		}
+	})
+
+	t.Run("unverified email doesn't take over a local account", func(t *testing.T) {
+		users := NewInMemoryUserStore()
+		local, _ := users.AddUser(models.User{Email: fake.User.Email, PasswordHash: "x"})
+		_, app := newSSOApp(t, fake, users)
+		fake.User.EmailVerified = false
+		defer func() { fake.User.EmailVerified = true }()
+
+		res, err := newBrowser().Get(app.URL + "/auth/oidc/login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		assertCode(t, res.StatusCode, http.StatusConflict)
+		if user, _ := users.GetUser(local.ID); user.OIDCSubject != "" {
+			t.Errorf("local account was linked with an unverified email")
+		}
+	})
+
+	t.Run("callback with wrong state is rejected", func(t *testing.T) {
+		_, app := newSSOApp(t, fake, NewInMemoryUserStore())
+		browser := newBrowser()
+		browser.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+		res, err := browser.Get(app.URL + "/auth/oidc/login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		res, err = browser.Get(app.URL + "/auth/oidc/callback?code=abc&state=forged")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		assertCode(t, res.StatusCode, http.StatusBadRequest)
+	})
+
+	t.Run("id token for another client is rejected", func(t *testing.T) {
+		users := NewInMemoryUserStore()
+		_, app := newSSOApp(t, fake, users)
+		fake.Claims = func(c map[string]any) { c["aud"] = "another-app" }
+		defer func() { fake.Claims = nil }()
+
+		res, err := newBrowser().Get(app.URL + "/auth/oidc/login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		assertCode(t, res.StatusCode, http.StatusUnauthorized)
+		if len(users.users) != 0 {
+			t.Errorf("user was provisioned from an invalid token")
+		}
+	})
+
+	t.Run("sso routes are missing without a provider", func(t *testing.T) {
+		server := NewContactServer(&StubContactStore{})
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, newGetRequest("/auth/oidc/login"))
+		assertCode(t, res.Code, http.StatusNotFound)
+	})
+}
+
+func TestLocalLogin(t *testing.T) {
+	users := NewInMemoryUserStore()
+	hash, err := HashPassword("hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, _ := users.AddUser(models.User{Email: "reza@example.com", PasswordHash: hash})
+	server := NewContactServer(&StubContactStore{}, WithUserStore(users))
+
+	loginRequest := func(session *Session, email, password string) *http.Request {
+		f := url.Values{}
+		f.Set(views.LoginFormEmail, email)
+		f.Set(views.LoginFormPassword, password)
+		req, _ := http.NewRequest(http.MethodPost, "/login", strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return withSession(req, session)
+	}
+
+	t.Run("correct password logs in with a new session", func(t *testing.T) {
+		session := server.sessions.New()
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, loginRequest(session, "reza@example.com", "hunter2"))
+		assertRedirect(t, res, "/contacts")
+
+		if server.sessions.Get(session.ID) != nil {
+			t.Errorf("pre-login session should be discarded")
+		}
+		cookies := res.Result().Cookies()
+		if len(cookies) != 1 {
+			t.Fatalf("expected a new session cookie, got %v", cookies)
+		}
+		if got := server.sessions.Get(cookies[0].Value); got == nil || got.UserID != user.ID {
+			t.Errorf("new session isn't logged in as user %d", user.ID)
+		}
+	})
+
+	t.Run("wrong password is rejected", func(t *testing.T) {
+		session := server.sessions.New()
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, loginRequest(session, "reza@example.com", "wrong"))
+		assertCode(t, res.Code, http.StatusUnauthorized)
+		if session.UserID != 0 {
+			t.Errorf("session was logged in with a wrong password")
+		}
+	})
+
+	t.Run("sso only account can't log in with a password", func(t *testing.T) {
+		users.AddUser(models.User{Email: "sso@example.com"})
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, loginRequest(server.sessions.New(), "sso@example.com", ""))
+		assertCode(t, res.Code, http.StatusUnauthorized)
+	})
+
+	t.Run("logout ends the session", func(t *testing.T) {
+		session := server.sessions.New()
+		session.UserID = user.ID
+		req, _ := http.NewRequest(http.MethodPost, "/logout", nil)
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		assertRedirect(t, res, "/login")
+		if server.sessions.Get(session.ID) != nil {
+			t.Errorf("session should be discarded on logout")
+		}
+	})
+}
+
+func TestRequireUser(t *testing.T) {
+	server := NewContactServer(&StubContactStore{})
+
+	t.Run("anonymous visitors are sent to the login page", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, newGetRequest("/contacts"))
+		assertRedirect(t, res, "/login")
+	})
+
+	t.Run("logging in is open to them", func(t *testing.T) {
+		for _, path := range []string{"/login", "/static/site.css"} {
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, newGetRequest(path))
+			assertCode(t, res.Code, http.StatusOK)
+		}
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, newGetRequest("/auth/oidc/login"))
+		assertCode(t, res.Code, http.StatusNotFound)
+	})
+
+	t.Run("the calendar feed checks its own token", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, newGetRequest("/calendar/unknown.ics"))
+		assertCode(t, res.Code, http.StatusNotFound)
+	})
+
+	t.Run("logged in users get the page", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(newGetRequest("/contacts"), signedIn(server)))
+		assertCode(t, res.Code, http.StatusOK)
+	})
+}
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !CheckPassword(hash, "secret") {
+		t.Errorf("correct password didn't match its hash")
+	}
+	if CheckPassword(hash, "Secret") {
+		t.Errorf("wrong password matched")
+	}
+	if CheckPassword("garbage", "secret") {
+		t.Errorf("malformed hash matched")
+	}
+}
@@ -0,0 +1,116 @@
+// Package blob keeps opaque binary data, like photos, by key.
+package blob
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+var validKey = regexp.MustCompile(`^[a-zA-Z0-9_-][a-zA-Z0-9._-]*$`)
+
+// ValidKey reports whether key is safe to use as a file name
+func ValidKey(key string) bool {
+	return validKey.MatchString(key)
+}
+
+// Dir stores every blob as a file in a local directory
+type Dir struct {
+	root string
+}
+
+func NewDir(root string) (*Dir, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &Dir{root: root}, nil
+}
+
+// Put writes to a temporary file first so readers never see half a blob
+func (d *Dir) Put(key string, data []byte) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	f, err := os.CreateTemp(d.root, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(d.root, key))
+}
+
+func (d *Dir) Get(key string) ([]byte, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+	data, err := os.ReadFile(filepath.Join(d.root, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (d *Dir) Delete(key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	err := os.Remove(filepath.Join(d.root, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Memory keeps blobs in memory, for tests and as a default
+type Memory struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{blobs: make(map[string][]byte)}
+}
+
+func (m *Memory) Put(key string, data []byte) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *Memory) Get(key string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (m *Memory) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.blobs[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.blobs, key)
+	return nil
+}
@@ -0,0 +1,39 @@
+package blob
+
+import (
+	"bytes"
+	"testing"
+)
+
+type store interface {
+	Put(string, []byte) error
+	Get(string) ([]byte, error)
+	Delete(string) error
+}
+
+func TestStores(t *testing.T) {
+	dir, err := NewDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, s := range map[string]store{"dir": dir, "memory": NewMemory()} {
+		t.Run(name, func(t *testing.T) {
+			if err := s.Put("abc.jpg", []byte("data")); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Get("abc.jpg")
+			if err != nil || !bytes.Equal(got, []byte("data")) {
+				t.Errorf("got %q, %v", got, err)
+			}
+			if err := s.Delete("abc.jpg"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Get("abc.jpg"); err != ErrNotFound {
+				t.Errorf("got %v after delete, wanted ErrNotFound", err)
+			}
+			if err := s.Put("../escape", nil); err != ErrInvalidKey {
+				t.Errorf("got %v for a path outside the store, wanted ErrInvalidKey", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package contactapp
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rezbow/contact-app/export"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+const calendarName = "Contacts"
+
+// calendarURL is where calendar apps subscribe to the feed of user, empty
+// when they have no token yet. it has to be absolute to be pasted into a
+// calendar app.
+func calendarURL(r *http.Request, user *models.User) string {
+	if user == nil || user.CalendarToken == "" {
+		return ""
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/calendar/%s.ics", scheme, r.Host, user.CalendarToken)
+}
+
+// /calendar/token hands out a new calendar feed url, the old one stops working
+func (s *Server) resetCalendarToken(w http.ResponseWriter, r *http.Request) {
+	user := views.CurrentUser(r.Context())
+	if user == nil {
+		redirect(w, r, "/login")
+		return
+	}
+	updated := *user
+	updated.CalendarToken = randomToken()
+	if err := s.users.EditUser(updated); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	redirect(w, r, "/dashboard")
+}
+
+// /calendar/{token}.ics is the iCalendar feed of birthdays and open reminders.
+// calendar apps can't sign in, the token in the url is what protects it.
+func (s *Server) getCalendar(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutSuffix(r.PathValue("file"), ".ics")
+	if !ok || token == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if _, err := s.users.GetUserByCalendarToken(token); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := export.WriteICalendar(w, calendarName, s.calendarEvents(uidDomain(r)), time.Now()); err != nil {
+		log.Println(err)
+	}
+}
+
+// uidDomain is the host the feed was requested from, without its port. it
+// qualifies event uids so they are unique beyond this server (RFC 5545
+// section 3.8.4.7).
+func uidDomain(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.Host); err == nil {
+		return host
+	}
+	return r.Host
+}
+
+// calendarEvents are the birthdays of every contact and the reminders that
+// aren't done. uids derive from ids and domain so they are stable across
+// feeds.
+func (s *Server) calendarEvents(domain string) []export.Event {
+	var events []export.Event
+	for _, contact := range s.store.AllContacts() {
+		if !contact.HasBirthday() {
+			continue
+		}
+		events = append(events, export.Event{
+			UID:     fmt.Sprintf("contact-%d-birthday@%s", contact.ID, domain),
+			Date:    contact.Birthday,
+			Summary: strings.TrimSpace(contact.FirstName+" "+contact.LastName) + "'s birthday",
+			Yearly:  true,
+		})
+	}
+	for _, reminder := range s.reminders.OpenReminders() {
+		contact, err := s.store.GetContact(reminder.ContactID)
+		if err != nil {
+			continue
+		}
+		events = append(events, export.Event{
+			UID:         fmt.Sprintf("reminder-%d@%s", reminder.ID, domain),
+			Date:        reminder.Due,
+			Summary:     reminder.Note,
+			Description: "Reminder about " + strings.TrimSpace(contact.FirstName+" "+contact.LastName),
+		})
+	}
+	return events
+}
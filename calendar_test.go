@@ -0,0 +1,85 @@
+package contactapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	store := NewinMemoryStore()
+	users := NewInMemoryUserStore()
+	user, _ := users.AddUser(models.User{Email: "planner@example.com"})
+	reminders := NewInMemoryReminderStore()
+	server := NewContactServer(store, WithUserStore(users), WithReminderStore(reminders))
+	session := server.sessions.New()
+	session.UserID = user.ID
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	resetToken := func() string {
+		req, _ := http.NewRequest(http.MethodPost, "/calendar/token", nil)
+		assertRedirect(t, serve(req), "/dashboard")
+		user, _ := users.GetUser(user.ID)
+		return user.CalendarToken
+	}
+	feed := func(token string) *httptest.ResponseRecorder {
+		// calendar apps come without a session
+		res := httptest.NewRecorder()
+		req := newGetRequest("/calendar/" + token + ".ics")
+		req.Host = "contacts.example.com:8080"
+		server.ServeHTTP(res, req)
+		return res
+	}
+
+	jack, _ := store.GetContact(1)
+	jack.Birthday = time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+	store.EditContact(jack)
+	reminders.AddReminder(models.Reminder{ContactID: 2, Due: time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC), Note: "renew contract"})
+	reminders.AddReminder(models.Reminder{ContactID: 2, Due: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), Note: "old news", Done: true})
+
+	t.Run("feed needs a valid token", func(t *testing.T) {
+		assertCode(t, feed("").Code, http.StatusNotFound)
+		assertCode(t, feed("guess").Code, http.StatusNotFound)
+	})
+
+	t.Run("feed has birthdays and open reminders", func(t *testing.T) {
+		token := resetToken()
+		if !strings.Contains(serve(newGetRequest("/dashboard")).Body.String(), "/calendar/"+token+".ics") {
+			t.Errorf("expected the feed url on the dashboard")
+		}
+		res := feed(token)
+		assertCode(t, res.Code, http.StatusOK)
+		if got := res.Header().Get("Content-Type"); got != "text/calendar; charset=utf-8" {
+			t.Errorf("got content type %q", got)
+		}
+		body := res.Body.String()
+		for _, want := range []string{
+			"UID:contact-1-birthday@contacts.example.com\r\n",
+			"SUMMARY:Jack Jackson's birthday\r\n",
+			"UID:reminder-1@contacts.example.com\r\n",
+			"SUMMARY:renew contract\r\n",
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected feed to contain %q, got\n%s", want, body)
+			}
+		}
+		if strings.Contains(body, "old news") {
+			t.Errorf("expected done reminders to be left out")
+		}
+	})
+
+	t.Run("resetting the token retires the old feed url", func(t *testing.T) {
+		old := resetToken()
+		token := resetToken()
+		assertCode(t, feed(old).Code, http.StatusNotFound)
+		assertCode(t, feed(token).Code, http.StatusOK)
+	})
+}
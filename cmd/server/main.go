@@ -1,16 +1,109 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
+	"strconv"
+	"time"
 
 	contactapp "github.com/rezbow/contact-app"
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/blob"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/oidc"
+	"github.com/rezbow/contact-app/phone"
 )
 
 func main() {
-	store := contactapp.NewinMemoryStore()
-	server := contactapp.NewContactServer(store)
+	var storeOptions []contactapp.InMemoryStoreOption
+	// 0 to 1, the higher the fewer typos search forgives
+	if similarity := os.Getenv("SEARCH_MIN_SIMILARITY"); similarity != "" {
+		min, err := strconv.ParseFloat(similarity, 64)
+		if err != nil || min < 0 || min > 1 {
+			log.Fatalf("SEARCH_MIN_SIMILARITY must be a number from 0 to 1, got %q", similarity)
+		}
+		storeOptions = append(storeOptions, contactapp.WithMinSimilarity(min))
+	}
+	store := contactapp.NewinMemoryStore(storeOptions...)
+	users := contactapp.NewInMemoryUserStore()
+	auditPath := os.Getenv("AUDIT_LOG")
+	if auditPath == "" {
+		auditPath = "audit.log"
+	}
+	auditLog, err := audit.Open(auditPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer auditLog.Close()
+	options := []contactapp.Option{
+		contactapp.WithUserStore(users),
+		contactapp.WithAuditLog(auditLog),
+	}
+
+	// local account for bootstrapping, e.g. ADMIN_EMAIL=me@example.com ADMIN_PASSWORD=...
+	if email, password := os.Getenv("ADMIN_EMAIL"), os.Getenv("ADMIN_PASSWORD"); email != "" && password != "" {
+		hash, err := contactapp.HashPassword(password)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := users.AddUser(models.User{Email: email, PasswordHash: hash}); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		provider, err := oidc.Discover(context.Background(), oidc.Config{
+			IssuerURL:    issuer,
+			ClientID:     os.Getenv("OIDC_CLIENT_ID"),
+			ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		options = append(options, contactapp.WithOIDC(provider))
+	}
+
+	photoDir := os.Getenv("PHOTO_DIR")
+	if photoDir == "" {
+		photoDir = "photos"
+	}
+	blobs, err := blob.NewDir(photoDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	options = append(options, contactapp.WithBlobStore(blobs))
+
+	if retention := os.Getenv("TRASH_RETENTION"); retention != "" {
+		d, err := time.ParseDuration(retention)
+		if err != nil {
+			log.Fatal(err)
+		}
+		options = append(options, contactapp.WithTrashRetention(d))
+	}
+
+	if timeout := os.Getenv("SESSION_IDLE_TIMEOUT"); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		options = append(options, contactapp.WithSessionIdleTimeout(d))
+	}
+
+	if region := os.Getenv("PHONE_REGION"); region != "" {
+		if !phone.ValidRegion(region) {
+			log.Fatalf("unknown phone region %q", region)
+		}
+		options = append(options, contactapp.WithPhoneRegion(region))
+	}
+
+	server := contactapp.NewContactServer(store, options...)
+	go server.RunTrashPurger(context.Background(), time.Hour)
+	go server.RunReminderTicker(context.Background(), time.Minute)
+	go server.RunSessionCleanup(context.Background(), 10*time.Minute)
 	http.DefaultServeMux.Handle("/", server)
 	log.Println(http.ListenAndServe(":8080", http.DefaultServeMux))
 }
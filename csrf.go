@@ -0,0 +1,41 @@
+package contactapp
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/rezbow/contact-app/views"
+)
+
+var ErrInvalidCSRFToken = errors.New("invalid csrf token")
+
+// csrf makes sure state-changing requests carry the token of their session,
+// either in the htmx header or in the form body. it expects loadSession to
+// run first.
+func (s *Server) csrf(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := sessionFromContext(r.Context())
+		if !isSafeMethod(r.Method) && (session == nil || !validCSRFToken(r, session.CSRFToken)) {
+			http.Error(w, ErrInvalidCSRFToken.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func validCSRFToken(r *http.Request, want string) bool {
+	got := r.Header.Get(views.CSRFHeaderName)
+	if got == "" {
+		got = r.PostFormValue(views.CSRFFieldName)
+	}
+	return got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
@@ -0,0 +1,125 @@
+package contactapp
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/search"
+	"github.com/rezbow/contact-app/views"
+)
+
+// /contacts/duplicates lists the contacts that may be the same person
+func (s *Server) getDuplicates(w http.ResponseWriter, r *http.Request) {
+	duplicates := search.FindDuplicates(s.store.AllContacts(), search.DefaultMinDuplicateScore)
+	render(w, r.Context(), views.Duplicates(duplicates))
+}
+
+// /contacts/merge?a=1&b=2
+func (s *Server) mergeContactsPage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	aID, _ := strconv.Atoi(q.Get(views.MergeFormA))
+	bID, _ := strconv.Atoi(q.Get(views.MergeFormB))
+	if aID == bID {
+		http.Error(w, "can't merge a contact with itself", http.StatusBadRequest)
+		return
+	}
+	a, errA := s.store.GetContact(aID)
+	b, errB := s.store.GetContact(bID)
+	if errA != nil || errB != nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.renderMerge(w, r, a, b, views.NewMergeForm(a, b))
+}
+
+func (s *Server) renderMerge(w http.ResponseWriter, r *http.Request, a, b models.Contact, form *views.MergeForm) {
+	render(w, r.Context(), views.MergeContacts(views.MergeViewModel{
+		A:       a,
+		B:       b,
+		Choices: models.MergeChoices(a, b),
+		Form:    form,
+	}))
+}
+
+// /contacts/merge merges one contact into the other: the other goes away
+// and its timeline, reminders, relationships and groups move to the one
+// kept
+func (s *Server) mergeContacts(w http.ResponseWriter, r *http.Request) {
+	form := views.MergeFormFromRequest(r)
+	a, errA := s.store.GetContact(form.A)
+	b, errB := s.store.GetContact(form.B)
+	if errA != nil || errB != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !form.Valid() {
+		s.renderMerge(w, r, a, b, form)
+		return
+	}
+	if a.Version != form.VersionA || b.Version != form.VersionB {
+		s.renderMergeConflict(w, r, a, b)
+		return
+	}
+	keep, other := a, b
+	if form.Keep == b.ID {
+		keep, other = b, a
+	}
+	merged := models.MergeContacts(keep, other, form.FromOther())
+	// the other contact goes first, so the emails it gives up are free
+	if err := s.store.DeleteContact(other.ID); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := s.store.EditContact(merged); err != nil {
+		if err := s.store.RestoreContact(other.ID); err != nil {
+			log.Println(err)
+		}
+		switch err {
+		case ErrEditConflict:
+			s.renderMergeConflict(w, r, a, b)
+		case ErrNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	if err := s.store.PurgeContact(other.ID); err != nil {
+		log.Println(err)
+	}
+	s.relations.MoveContactRelationships(other.ID, keep.ID)
+	s.interactions.MoveContactInteractions(other.ID, keep.ID)
+	s.reminders.MoveContactReminders(other.ID, keep.ID)
+	// the history of the other contact follows that of the one kept
+	s.recordBaseline(keep)
+	s.recordBaseline(other)
+	s.revisions.MoveContactRevisions(other.ID, keep.ID)
+	for _, group := range s.groups.Groups() {
+		if group.HasMember(other.ID) {
+			group.ReplaceMember(other.ID, keep.ID)
+			if err := s.groups.EditGroup(group); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+	s.updateLastContacted(keep.ID)
+	s.recordChange(r, audit.ActionMerge, other, models.Contact{})
+	s.recordChange(r, audit.ActionMerge, keep, merged)
+	s.recordRevision(r, keep, merged, 0)
+	redirect(w, r, fmt.Sprintf("/contacts/%d", keep.ID))
+}
+
+// renderMergeConflict asks to review contacts that changed while the merge
+// form was open
+func (s *Server) renderMergeConflict(w http.ResponseWriter, r *http.Request, a, b models.Contact) {
+	a, _ = s.store.GetContact(a.ID)
+	b, _ = s.store.GetContact(b.ID)
+	form := views.NewMergeForm(a, b)
+	form.Errors.Set(views.MergeFormKeep, "these contacts changed since, review them again")
+	w.WriteHeader(http.StatusConflict)
+	s.renderMerge(w, r, a, b, form)
+}
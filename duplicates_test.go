@@ -0,0 +1,119 @@
+package contactapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestMergeDuplicates(t *testing.T) {
+	store := NewinMemoryStore()
+	groups := NewInMemoryGroupStore()
+	relations := NewInMemoryRelationshipStore()
+	interactions := NewInMemoryInteractionStore()
+	reminders := NewInMemoryReminderStore()
+	revisions := NewInMemoryRevisionStore()
+	server := NewContactServer(store,
+		WithRevisionStore(revisions),
+		WithGroupStore(groups),
+		WithRelationshipStore(relations),
+		WithInteractionStore(interactions),
+		WithReminderStore(reminders),
+	)
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	merge := func(f url.Values) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/contacts/merge", strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+
+	dup, _ := store.AddContact(models.Contact{
+		FirstName: "Jack", LastName: "Jakson", Company: "Acme", Tags: []string{"golf"},
+		Emails: []models.Email{{Label: "work", Address: "jack@work.com"}},
+	})
+	interactions.AddInteraction(models.Interaction{ContactID: dup.ID, Type: "call", Time: time.Now()})
+	reminders.AddReminder(models.Reminder{ContactID: dup.ID, Due: models.DateOf(time.Now()), Note: "call back"})
+	relations.AddRelationship(models.Relationship{ContactID: 2, RelatedID: dup.ID, Type: "friend"})
+	groups.AddGroup(models.Group{Name: "Golf", Members: []int{dup.ID, 2}})
+
+	t.Run("duplicates are listed for review", func(t *testing.T) {
+		body := serve(newGetRequest("/contacts/duplicates")).Body.String()
+		if !strings.Contains(body, `href="/contacts/merge?a=1&amp;b=4"`) || !strings.Contains(body, "similar name") {
+			t.Errorf("expected Jack Jackson and Jack Jakson to be listed, got %s", body)
+		}
+	})
+
+	t.Run("merge page picks the fields of the older contact", func(t *testing.T) {
+		body := serve(newGetRequest("/contacts/merge?a=1&b=4")).Body.String()
+		for _, want := range []string{
+			`name="pick.last_name" value="1" checked`,
+			// the older contact has no company
+			`name="pick.company" value="4" checked`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected %q on the merge page", want)
+			}
+		}
+		assertCode(t, serve(newGetRequest("/contacts/merge?a=1&b=1")).Code, http.StatusBadRequest)
+	})
+
+	form := url.Values{
+		"a": {"1"}, "b": {"4"}, "a_version": {"0"}, "b_version": {"1"},
+		"keep": {"1"}, "pick.last_name": {"4"}, "pick.company": {"4"},
+	}
+
+	t.Run("stale contacts are reviewed again", func(t *testing.T) {
+		stale := url.Values{}
+		for k, v := range form {
+			stale[k] = v
+		}
+		stale.Set("b_version", "0")
+		assertCode(t, merge(stale).Code, http.StatusConflict)
+	})
+
+	t.Run("merge into the contact kept", func(t *testing.T) {
+		assertRedirect(t, merge(form), "/contacts/1")
+		merged, _ := store.GetContact(1)
+		if merged.LastName != "Jakson" || merged.Company != "Acme" || !merged.HasEmail("jack@work.com") ||
+			!merged.HasEmail("jack@jaskcons.com") || !merged.HasTag("golf") || !merged.Contacted() {
+			t.Errorf("got %+v", merged)
+		}
+		if _, err := store.GetContact(dup.ID); err == nil || len(store.GetTrash()) != 0 {
+			t.Errorf("expected the duplicate to be gone")
+		}
+		if got := len(interactions.Interactions(1)); got != 1 {
+			t.Errorf("got %d interactions, wanted 1", got)
+		}
+		if got := reminders.Reminders(1); len(got) != 1 || got[0].Note != "call back" {
+			t.Errorf("got reminders %+v", got)
+		}
+		if got := relations.Relationships(2); len(got) != 1 || got[0].RelatedID != 1 {
+			t.Errorf("got relationships %+v", got)
+		}
+		if group, _ := groups.GetGroup(1); !reflect.DeepEqual(group.Members, []int{1, 2}) {
+			t.Errorf("got members %v", group.Members)
+		}
+		history := revisions.Revisions(1)
+		if len(history) != 3 || history[1].MergedFrom != dup.ID || history[1].Contact.Company != "Acme" || history[2].Number != 3 {
+			t.Errorf("expected the history of the duplicate between that of Jack and the merge, got %+v", history)
+		}
+		if got := revisions.Revisions(dup.ID); len(got) != 0 {
+			t.Errorf("got %d revisions left on the merged away contact", len(got))
+		}
+		if body := serve(newGetRequest("/contacts/1/history")).Body.String(); !strings.Contains(body, "merged into this contact") {
+			t.Errorf("expected the merged revision to be labelled")
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+// WriteCSV writes one row per contact after a header row. multi-valued fields
+// go into one cell as "label: value" pairs joined by "; ", and tags are joined
+// by ", ". every custom field gets a column named "custom.<key>", in schema
+// order. cells that a spreadsheet would run as a formula are escaped.
+func WriteCSV(w io.Writer, contacts []models.Contact, fields []models.FieldDefinition) error {
+	header := []string{"id", "first_name", "last_name", "phones", "emails", "addresses",
+		"company", "job_title", "birthday", "website", "notes", "tags"}
+	for _, field := range fields {
+		header = append(header, "custom."+field.Key)
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, c := range contacts {
+		var phones, emails, addresses []string
+		for _, p := range c.Phones {
+			phones = append(phones, p.Label+": "+p.Number)
+		}
+		for _, e := range c.Emails {
+			emails = append(emails, e.Label+": "+e.Address)
+		}
+		for _, a := range c.Addresses {
+			addresses = append(addresses, a.Label+": "+a.String())
+		}
+		var birthday string
+		if c.HasBirthday() {
+			birthday = c.Birthday.Format(time.DateOnly)
+		}
+		row := []string{strconv.Itoa(c.ID), c.FirstName, c.LastName,
+			strings.Join(phones, "; "), strings.Join(emails, "; "), strings.Join(addresses, "; "),
+			c.Company, c.JobTitle, birthday, c.Website, c.Notes, strings.Join(c.Tags, ", ")}
+		for _, field := range fields {
+			row = append(row, c.Custom[field.Key])
+		}
+		for i := range row {
+			row[i] = escapeFormula(row[i])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// escapeFormula quotes a cell starting with =, +, - or @ so spreadsheets show
+// it as text instead of evaluating it
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
@@ -0,0 +1,27 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestWriteCSV(t *testing.T) {
+	contacts := []models.Contact{{
+		ID: 7, FirstName: "=HYPERLINK(\"http://evil.example\")", LastName: "Jackson",
+		Phones: []models.Phone{{Label: "work", Number: "+1 555 0100"}},
+		Tags:   []string{"golf", "vip"},
+		Custom: map[string]string{"tier": "@SUM(A1:A9)", "balance": "-3"},
+	}}
+	fields := []models.FieldDefinition{{Key: "tier"}, {Key: "balance"}, {Key: "empty"}}
+	var b strings.Builder
+	if err := WriteCSV(&b, contacts, fields); err != nil {
+		t.Fatal(err)
+	}
+	want := "id,first_name,last_name,phones,emails,addresses,company,job_title,birthday,website,notes,tags,custom.tier,custom.balance,custom.empty\n" +
+		`7,"'=HYPERLINK(""http://evil.example"")",Jackson,work: +1 555 0100,,,,,,,,"golf, vip",'@SUM(A1:A9),'-3,` + "\n"
+	if got := b.String(); got != want {
+		t.Errorf("got\n%s\nwanted\n%s", got, want)
+	}
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"io"
+	"time"
+)
+
+// Event is an all-day calendar event. all-day dates float, so they fall on
+// the same day in every time zone, which is what birthdays and due dates
+// want.
+type Event struct {
+	// stays the same across feeds so calendar clients update the event
+	// rather than adding another, e.g. reminder-3@example.com
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+	// repeats on the same day every year
+	Yearly bool
+}
+
+// WriteICalendar writes events as an RFC 5545 calendar. stamp is when the
+// feed was generated.
+func WriteICalendar(w io.Writer, name string, events []Event, stamp time.Time) error {
+	esc := vcardEscaper.Replace
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//rezbow//contact-app//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:" + esc(name),
+	}
+	for _, e := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+e.UID,
+			"DTSTAMP:"+stamp.UTC().Format("20060102T150405Z"),
+			"DTSTART;VALUE=DATE:"+e.Date.Format("20060102"),
+			"DTEND;VALUE=DATE:"+e.Date.AddDate(0, 0, 1).Format("20060102"),
+			"SUMMARY:"+esc(e.Summary),
+		)
+		if e.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+esc(e.Description))
+		}
+		if e.Yearly {
+			lines = append(lines, yearlyRule(e.Date))
+		}
+		lines = append(lines, "TRANSP:TRANSPARENT", "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+	for _, line := range lines {
+		if _, err := io.WriteString(w, foldLine(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// yearlyRule repeats an event every year. clients skip February 29 in other
+// years, so it moves to the last day of February instead.
+func yearlyRule(date time.Time) string {
+	if date.Month() == time.February && date.Day() == 29 {
+		return "RRULE:FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=-1"
+	}
+	return "RRULE:FREQ=YEARLY"
+}
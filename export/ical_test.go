@@ -0,0 +1,38 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICalendar(t *testing.T) {
+	events := []Event{
+		{UID: "contact-7-birthday", Date: time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC), Summary: "Jack's birthday", Yearly: true},
+		{UID: "leap-birthday", Date: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC), Summary: "Leap", Yearly: true},
+		{UID: "reminder-3", Date: time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), Summary: "Call Jack", Description: "about the offer, again; twice"},
+	}
+	stamp := time.Date(2026, 10, 19, 10, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	var buf bytes.Buffer
+	if err := WriteICalendar(&buf, "Contacts", events, stamp); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"UID:contact-7-birthday\r\nDTSTAMP:20261019T083000Z\r\nDTSTART;VALUE=DATE:19900517\r\nDTEND;VALUE=DATE:19900518\r\n",
+		"RRULE:FREQ=YEARLY\r\n",
+		"RRULE:FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=-1\r\n",
+		"DTSTART;VALUE=DATE:20261231\r\nDTEND;VALUE=DATE:20270101\r\n",
+		`DESCRIPTION:about the offer\, again\; twice` + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected calendar to contain %q, got\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "RRULE") != 2 {
+		t.Errorf("expected only birthdays to repeat")
+	}
+}
@@ -0,0 +1,121 @@
+// Package export writes contacts out in formats other tools understand.
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+// Contact is the exported shape of a contact. it is decoupled from
+// models.Contact so the file format doesn't change by accident.
+type Contact struct {
+	ID        int       `json:"id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Phones    []Phone   `json:"phones,omitempty"`
+	Emails    []Email   `json:"emails,omitempty"`
+	Addresses []Address `json:"addresses,omitempty"`
+	Company   string    `json:"company,omitempty"`
+	JobTitle  string    `json:"job_title,omitempty"`
+	// yyyy-mm-dd
+	Birthday string   `json:"birthday,omitempty"`
+	Website  string   `json:"website,omitempty"`
+	Notes    string   `json:"notes,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	// custom field values by field key
+	Custom map[string]string `json:"custom,omitempty"`
+}
+
+type Phone struct {
+	Label  string `json:"label"`
+	Number string `json:"number"`
+	E164   string `json:"e164,omitempty"`
+}
+
+type Email struct {
+	Label   string `json:"label"`
+	Address string `json:"address"`
+}
+
+type Address struct {
+	Label      string `json:"label"`
+	Street     string `json:"street,omitempty"`
+	City       string `json:"city,omitempty"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	Country    string `json:"country,omitempty"`
+}
+
+func FromModel(c models.Contact) Contact {
+	contact := Contact{
+		ID:        c.ID,
+		FirstName: c.FirstName,
+		LastName:  c.LastName,
+		Company:   c.Company,
+		JobTitle:  c.JobTitle,
+		Website:   c.Website,
+		Notes:     c.Notes,
+		Tags:      slices.Clone(c.Tags),
+		Custom:    maps.Clone(c.Custom),
+	}
+	if c.HasBirthday() {
+		contact.Birthday = c.Birthday.Format(time.DateOnly)
+	}
+	for _, p := range c.Phones {
+		contact.Phones = append(contact.Phones, Phone{Label: p.Label, Number: p.Number, E164: p.E164})
+	}
+	for _, e := range c.Emails {
+		contact.Emails = append(contact.Emails, Email{Label: e.Label, Address: e.Address})
+	}
+	for _, a := range c.Addresses {
+		contact.Addresses = append(contact.Addresses, Address(a))
+	}
+	return contact
+}
+
+func (c Contact) ToModel() (models.Contact, error) {
+	contact := models.Contact{
+		ID:        c.ID,
+		FirstName: c.FirstName,
+		LastName:  c.LastName,
+		Company:   c.Company,
+		JobTitle:  c.JobTitle,
+		Website:   c.Website,
+		Notes:     c.Notes,
+		Tags:      slices.Clone(c.Tags),
+		Custom:    maps.Clone(c.Custom),
+	}
+	if c.Birthday != "" {
+		birthday, err := time.Parse(time.DateOnly, c.Birthday)
+		if err != nil {
+			return models.Contact{}, err
+		}
+		contact.Birthday = birthday
+	}
+	for _, p := range c.Phones {
+		contact.Phones = append(contact.Phones, models.Phone{Label: p.Label, Number: p.Number, E164: p.E164})
+	}
+	for _, e := range c.Emails {
+		contact.Emails = append(contact.Emails, models.Email{Label: e.Label, Address: e.Address})
+	}
+	for _, a := range c.Addresses {
+		contact.Addresses = append(contact.Addresses, models.Address(a))
+	}
+	return contact, nil
+}
+
+// WriteJSON writes contacts as an indented json array
+func WriteJSON(w io.Writer, contacts []models.Contact) error {
+	exported := make([]Contact, 0, len(contacts))
+	for _, c := range contacts {
+		exported = append(exported, FromModel(c))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(exported)
+}
@@ -0,0 +1,57 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestJSON(t *testing.T) {
+	contacts := []models.Contact{
+		{
+			ID: 1, FirstName: "Jack", LastName: "Jackson",
+			Phones:    []models.Phone{{Label: "work", Number: "123"}},
+			Emails:    []models.Email{{Label: "home", Address: "jack@jackson.com"}},
+			Addresses: []models.Address{{Label: "home", Street: "1 Main St", City: "Springfield"}},
+			Company:   "Acme",
+			JobTitle:  "Engineer",
+			Birthday:  time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC),
+			Website:   "https://jack.dev",
+			Notes:     "met at **GopherCon**",
+			Tags:      []string{"customer", "vip"},
+			Custom:    map[string]string{"tier": "gold"},
+		},
+		{ID: 2, FirstName: "John", LastName: "Doe"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, contacts); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"company": "Acme"`, `"job_title": "Engineer"`, `"birthday": "1990-05-17"`, `"website": "https://jack.dev"`, `"notes": "met at **GopherCon**"`, `"tier": "gold"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected export to contain %s", want)
+		}
+	}
+
+	var exported []Contact
+	if err := json.NewDecoder(&buf).Decode(&exported); err != nil {
+		t.Fatal(err)
+	}
+	var got []models.Contact
+	for _, c := range exported {
+		contact, err := c.ToModel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, contact)
+	}
+	if !reflect.DeepEqual(got, contacts) {
+		t.Errorf("round trip changed contacts:\ngot  %+v\nwant %+v", got, contacts)
+	}
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+// recipients are the primary emails of contacts, contacts without one are
+// left out
+func recipients(contacts []models.Contact) []mail.Address {
+	var addresses []mail.Address
+	for _, c := range contacts {
+		if email := c.PrimaryEmail(); email != "" {
+			name := strings.TrimSpace(c.FirstName + " " + c.LastName)
+			addresses = append(addresses, mail.Address{Name: name, Address: email})
+		}
+	}
+	return addresses
+}
+
+// AddressList formats contacts as an RFC 5322 address list, e.g.
+// `"Jack Jackson" <jack@jackson.com>, "John Doe" <john@doe.com>`
+func AddressList(contacts []models.Contact) string {
+	var list []string
+	for _, address := range recipients(contacts) {
+		list = append(list, address.String())
+	}
+	return strings.Join(list, ", ")
+}
+
+// MailtoURL is a mailto: link addressed to every contact
+func MailtoURL(contacts []models.Contact) string {
+	var list []string
+	for _, address := range recipients(contacts) {
+		list = append(list, url.PathEscape(address.Address))
+	}
+	return "mailto:" + strings.Join(list, ",")
+}
+
+// WriteEmailCSV writes a name and email row per contact after a header row
+func WriteEmailCSV(w io.Writer, contacts []models.Contact) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"name", "email"}); err != nil {
+		return err
+	}
+	for _, address := range recipients(contacts) {
+		if err := cw.Write([]string{address.Name, address.Address}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
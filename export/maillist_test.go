@@ -0,0 +1,21 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestMailingList(t *testing.T) {
+	contacts := []models.Contact{
+		{FirstName: "Jack", LastName: "O'Neil, Jr.", Emails: []models.Email{{Label: "work", Address: "jack@sgc.mil"}}},
+		{FirstName: "No", LastName: "Email"},
+		{FirstName: "Søren", LastName: "K", Emails: []models.Email{{Label: "home", Address: "s+k?x@example.com"}}},
+	}
+	if got, want := AddressList(contacts), `"Jack O'Neil, Jr." <jack@sgc.mil>, =?utf-8?q?S=C3=B8ren_K?= <s+k?x@example.com>`; got != want {
+		t.Errorf("got address list %q, wanted %q", got, want)
+	}
+	if got, want := MailtoURL(contacts), "mailto:jack@sgc.mil,s+k%3Fx@example.com"; got != want {
+		t.Errorf("got mailto %q, wanted %q", got, want)
+	}
+}
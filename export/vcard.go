@@ -0,0 +1,113 @@
+package export
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+// vcardEscaper escapes TEXT values as RFC 2426 wants, iCalendar escapes them
+// the same way
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+
+// WriteVCard writes contacts as vCard 3.0. photo returns the jpeg of a
+// contact, if it has one.
+func WriteVCard(w io.Writer, contacts []models.Contact, photo func(models.Contact) []byte) error {
+	for _, c := range contacts {
+		if err := writeVCard(w, c, photo(c)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeVCard(w io.Writer, c models.Contact, jpeg []byte) error {
+	esc := vcardEscaper.Replace
+	lines := []string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		"FN:" + esc(strings.TrimSpace(c.FirstName+" "+c.LastName)),
+		"N:" + esc(c.LastName) + ";" + esc(c.FirstName) + ";;;",
+	}
+	for _, p := range c.Phones {
+		number := p.Number
+		if p.E164 != "" {
+			number = p.E164
+		}
+		lines = append(lines, "TEL;TYPE="+vcardType(p.Label)+":"+esc(number))
+	}
+	for _, e := range c.Emails {
+		lines = append(lines, "EMAIL;TYPE=INTERNET,"+vcardType(e.Label)+":"+esc(e.Address))
+	}
+	for _, a := range c.Addresses {
+		lines = append(lines, "ADR;TYPE="+vcardType(a.Label)+":;;"+strings.Join([]string{
+			esc(a.Street), esc(a.City), esc(a.Region), esc(a.PostalCode), esc(a.Country),
+		}, ";"))
+	}
+	if c.Company != "" {
+		lines = append(lines, "ORG:"+esc(c.Company))
+	}
+	if c.JobTitle != "" {
+		lines = append(lines, "TITLE:"+esc(c.JobTitle))
+	}
+	if c.HasBirthday() {
+		lines = append(lines, "BDAY:"+c.Birthday.Format(time.DateOnly))
+	}
+	if c.Website != "" {
+		lines = append(lines, "URL:"+c.Website)
+	}
+	if c.Notes != "" {
+		lines = append(lines, "NOTE:"+esc(c.Notes))
+	}
+	if len(c.Tags) > 0 {
+		var tags []string
+		for _, tag := range c.Tags {
+			tags = append(tags, esc(tag))
+		}
+		lines = append(lines, "CATEGORIES:"+strings.Join(tags, ","))
+	}
+	if len(jpeg) > 0 {
+		lines = append(lines, "PHOTO;ENCODING=b;TYPE=JPEG:"+base64.StdEncoding.EncodeToString(jpeg))
+	}
+	lines = append(lines, fmt.Sprintf("UID:contact-%d", c.ID), "END:VCARD")
+	for _, line := range lines {
+		if _, err := io.WriteString(w, foldLine(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labels like "mobile" become the matching vCard type
+func vcardType(label string) string {
+	switch label {
+	case "mobile":
+		return "CELL"
+	case "":
+		return "OTHER"
+	}
+	return strings.ToUpper(label)
+}
+
+// foldLine ends line with CRLF, folding it into 75 octet pieces without
+// splitting a utf-8 sequence
+func foldLine(line string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		n := len(string(r))
+		if width+n > 75 {
+			b.WriteString("\r\n ")
+			// the leading space counts
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	b.WriteString("\r\n")
+	return b.String()
+}
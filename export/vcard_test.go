@@ -0,0 +1,51 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestVCard(t *testing.T) {
+	contact := models.Contact{
+		ID: 7, FirstName: "Jack", LastName: "Jackson",
+		Phones:    []models.Phone{{Label: "mobile", Number: "123"}},
+		Emails:    []models.Email{{Label: "work", Address: "jack@jackson.com"}},
+		Addresses: []models.Address{{Label: "home", Street: "1 Main St", City: "Springfield"}},
+		Company:   "Acme; Sons",
+		Birthday:  time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC),
+		Notes:     "line one\nline two, " + strings.Repeat("x", 100),
+		Tags:      []string{"customer"},
+	}
+	var buf bytes.Buffer
+	err := WriteVCard(&buf, []models.Contact{contact}, func(models.Contact) []byte { return []byte("jpeg") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"BEGIN:VCARD\r\nVERSION:3.0\r\n",
+		"N:Jackson;Jack;;;\r\n",
+		"TEL;TYPE=CELL:123\r\n",
+		"EMAIL;TYPE=INTERNET,WORK:jack@jackson.com\r\n",
+		"ADR;TYPE=HOME:;;1 Main St;Springfield;;;\r\n",
+		`ORG:Acme\; Sons` + "\r\n",
+		"BDAY:1990-05-17\r\n",
+		`NOTE:line one\nline two\, xxx`,
+		"CATEGORIES:customer\r\n",
+		"PHOTO;ENCODING=b;TYPE=JPEG:anBlZw==\r\n",
+		"END:VCARD\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected vcard to contain %q, got\n%s", want, got)
+		}
+	}
+	for _, line := range strings.Split(got, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package contactapp
+
+import (
+	"log"
+	"maps"
+	"net/http"
+
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+// /fields
+func (s *Server) getFields(w http.ResponseWriter, r *http.Request) {
+	render(w, r.Context(), views.Fields(views.FieldsViewModel{
+		Fields: s.fields.Fields(),
+		Form:   &views.FieldForm{Type: "text"},
+	}))
+}
+
+// saving a field with a key already in use redefines it
+func (s *Server) saveField(w http.ResponseWriter, r *http.Request) {
+	form := views.FieldFormFromRequest(r)
+	if !form.Valid() {
+		render(w, r.Context(), views.Fields(views.FieldsViewModel{Fields: s.fields.Fields(), Form: form}))
+		return
+	}
+	field := form.ToField()
+	if err := s.fields.SaveField(field); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	s.revalidateField(r, field)
+	redirect(w, r, "/fields")
+}
+
+// revalidateField checks the values stored for a field against its new
+// definition, putting them in canonical form and clearing the ones that
+// don't pass anymore
+func (s *Server) revalidateField(r *http.Request, field models.FieldDefinition) {
+	for _, before := range s.store.AllContacts() {
+		value, ok := before.Custom[field.Key]
+		if !ok {
+			continue
+		}
+		normalized, err := field.Normalize(value)
+		if err == nil && normalized == value {
+			continue
+		}
+		after := before
+		after.Custom = maps.Clone(before.Custom)
+		if err != nil || normalized == "" {
+			delete(after.Custom, field.Key)
+		} else {
+			after.Custom[field.Key] = normalized
+		}
+		if len(after.Custom) == 0 {
+			after.Custom = nil
+		}
+		if err := s.store.EditContact(after); err != nil {
+			log.Println(err)
+			continue
+		}
+		s.recordChange(r, audit.ActionEdit, before, after)
+		s.recordRevision(r, before, after, 0)
+	}
+}
+
+// /fields/{key}
+func (s *Server) deleteField(w http.ResponseWriter, r *http.Request) {
+	if err := s.fields.DeleteField(r.PathValue("key")); err != nil {
+		switch err {
+		case ErrFieldNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	renderString(w, "")
+}
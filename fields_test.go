@@ -0,0 +1,165 @@
+package contactapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+func TestCustomFields(t *testing.T) {
+	store := NewinMemoryStore()
+	schema := NewInMemoryFieldSchema()
+	server := NewContactServer(store, WithFieldSchema(schema))
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	postField := func(f url.Values) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/fields", strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+
+	t.Run("fields are defined on the management page", func(t *testing.T) {
+		res := postField(url.Values{
+			views.FieldFormKey:      {"team_size"},
+			views.FieldFormLabel:    {"Team Size"},
+			views.FieldFormType:     {"number"},
+			views.FieldFormRequired: {"true"},
+			views.FieldFormMin:      {"1"},
+		})
+		assertRedirect(t, res, "/fields")
+		res = postField(url.Values{
+			views.FieldFormKey:     {"tier"},
+			views.FieldFormLabel:   {"Tier"},
+			views.FieldFormType:    {"enum"},
+			views.FieldFormOptions: {"gold, silver"},
+		})
+		assertRedirect(t, res, "/fields")
+		if fields := schema.Fields(); len(fields) != 2 || fields[1].Options[1] != "silver" {
+			t.Errorf("got fields %+v", fields)
+		}
+	})
+
+	t.Run("invalid definitions are rejected", func(t *testing.T) {
+		res := postField(url.Values{
+			views.FieldFormKey:   {"Bad Key"},
+			views.FieldFormLabel: {"Bad"},
+			views.FieldFormType:  {"text"},
+		})
+		assertCode(t, res.Code, http.StatusOK)
+		if len(schema.Fields()) != 2 || !strings.Contains(res.Body.String(), models.ErrInvalidFieldID.Error()) {
+			t.Errorf("expected the bad key to be rejected")
+		}
+	})
+
+	t.Run("new contact form renders and validates custom fields", func(t *testing.T) {
+		res := serve(newGetRequest("/contacts/new"))
+		if !strings.Contains(res.Body.String(), `name="custom.team_size"`) {
+			t.Errorf("expected an input for team_size")
+		}
+
+		contact := models.Contact{FirstName: "Ada", LastName: "L", Phones: phones("(415) 555-0101"), Emails: emails("ada@l.com"),
+			Custom: map[string]string{"team_size": "0", "tier": "bronze"}}
+		res = serve(newContactRequest(contact))
+		assertCode(t, res.Code, http.StatusOK)
+		for _, want := range []string{"must be at least 1", "must be one of gold, silver"} {
+			if !strings.Contains(res.Body.String(), want) {
+				t.Errorf("expected error %q", want)
+			}
+		}
+
+		contact.Custom = map[string]string{"team_size": "12.0", "tier": "gold", "unknown": "x"}
+		res = serve(newContactRequest(contact))
+		assertRedirect(t, res, "/contacts")
+		all := store.AllContacts()
+		added := all[len(all)-1]
+		if want := map[string]string{"team_size": "12", "tier": "gold"}; !reflect.DeepEqual(added.Custom, want) {
+			t.Errorf("got custom values %v, wanted %v", added.Custom, want)
+		}
+	})
+
+	t.Run("custom values are searchable", func(t *testing.T) {
+		contacts := store.FilterContacts(models.ContactFilter{Query: "gold"}, firstPage).Contacts
+		if len(contacts) != 1 || contacts[0].FirstName != "Ada" {
+			t.Errorf("got %v searching for a custom value", contacts)
+		}
+	})
+
+	t.Run("csv export has a column per field", func(t *testing.T) {
+		res := serve(newGetRequest("/contacts/export?format=csv"))
+		assertCode(t, res.Code, http.StatusOK)
+		lines := strings.Split(strings.TrimSpace(res.Body.String()), "\n")
+		if !strings.HasSuffix(lines[0], ",custom.team_size,custom.tier") {
+			t.Errorf("got header %q", lines[0])
+		}
+		if !strings.HasSuffix(lines[len(lines)-1], ",12,gold") {
+			t.Errorf("got row %q", lines[len(lines)-1])
+		}
+	})
+
+	t.Run("removing a field", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/fields/tier", nil)
+		assertCode(t, serve(req).Code, http.StatusOK)
+		req, _ = http.NewRequest(http.MethodDelete, "/fields/tier", nil)
+		assertCode(t, serve(req).Code, http.StatusNotFound)
+
+		all := store.AllContacts()
+		ada := all[len(all)-1]
+		assertRedirect(t, serve(editContactRequest(ada)), "/contacts/"+strconv.Itoa(ada.ID))
+		if edited, _ := store.GetContact(ada.ID); !reflect.DeepEqual(edited.Custom, map[string]string{"team_size": "12"}) {
+			t.Errorf("got custom values %v, wanted the tier to be gone", edited.Custom)
+		}
+	})
+}
+
+func TestRedefinedCustomField(t *testing.T) {
+	store := NewinMemoryStore()
+	schema := NewInMemoryFieldSchema()
+	server := NewContactServer(store, WithFieldSchema(schema))
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	define := func(fieldType string) {
+		req, _ := http.NewRequest(http.MethodPost, "/fields", strings.NewReader(url.Values{
+			views.FieldFormKey:   {"home"},
+			views.FieldFormLabel: {"Home"},
+			views.FieldFormType:  {fieldType},
+		}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		assertRedirect(t, serve(req), "/fields")
+	}
+
+	define("text")
+	contact, _ := store.GetContact(1)
+	contact.Custom = map[string]string{"home": "javascript:alert(1)"}
+	assertRedirect(t, serve(editContactRequest(contact)), "/contacts/1")
+
+	define("url")
+	if current, _ := store.GetContact(1); current.Custom != nil {
+		t.Errorf("got custom values %v, wanted the value that isn't a url cleared", current.Custom)
+	}
+
+	// values that got in some other way still aren't links
+	current, _ := store.GetContact(1)
+	current.Custom = map[string]string{"home": "javascript:alert(1)"}
+	store.EditContact(current)
+	body := serve(newGetRequest("/contacts/1")).Body.String()
+	if strings.Contains(body, `href="javascript:`) || !strings.Contains(body, "javascript:alert(1)") {
+		t.Errorf("expected the value as plain text, got %s", body)
+	}
+}
@@ -0,0 +1,25 @@
+package contactapp
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+// /form-rows/{kind} returns a blank row for the multi-valued fields of the
+// contact forms
+func (s *Server) getFormRow(w http.ResponseWriter, r *http.Request) {
+	switch r.PathValue("kind") {
+	case "phone":
+		renderPartial(w, r.Context(), views.PhoneRow(models.Phone{}, ""))
+	case "email":
+		id, _ := strconv.Atoi(r.URL.Query().Get("contact"))
+		renderPartial(w, r.Context(), views.EmailRow(id, models.Email{}, ""))
+	case "address":
+		renderPartial(w, r.Context(), views.AddressRow(models.Address{}))
+	default:
+		http.NotFound(w, r)
+	}
+}
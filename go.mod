@@ -5,4 +5,5 @@ go 1.24.6
 require (
 	github.com/a-h/templ v0.3.960
 	github.com/sebdah/goldie v1.0.0
+	golang.org/x/text v0.28.0
 )
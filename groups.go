@@ -0,0 +1,218 @@
+package contactapp
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/rezbow/contact-app/export"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+// /groups
+func (s *Server) getGroups(w http.ResponseWriter, r *http.Request) {
+	render(w, r.Context(), views.Groups(views.GroupsViewModel{
+		Groups: s.groups.Groups(),
+		Form:   &views.GroupForm{},
+	}))
+}
+
+func (s *Server) newGroup(w http.ResponseWriter, r *http.Request) {
+	form := views.GroupFormFromRequest(r)
+	if !form.Valid() {
+		render(w, r.Context(), views.Groups(views.GroupsViewModel{Groups: s.groups.Groups(), Form: form}))
+		return
+	}
+	group, err := s.groups.AddGroup(models.Group{Name: form.Name, Description: form.Description})
+	if err != nil {
+		switch err {
+		case ErrDuplicateGroup:
+			form.Errors.Set(views.GroupFormName, err.Error())
+			render(w, r.Context(), views.Groups(views.GroupsViewModel{Groups: s.groups.Groups(), Form: form}))
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	redirect(w, r, fmt.Sprintf("/groups/%d", group.ID))
+}
+
+// /groups/members adds the contacts checked in the contact list to a group,
+// or all the contacts of a smart list
+func (s *Server) addSelectedToGroup(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	groupID, _ := strconv.Atoi(r.PostForm.Get(views.GroupFormGroup))
+	group, err := s.groups.GetGroup(groupID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	for _, id := range s.selectedContacts(r.PostForm) {
+		if _, err := s.store.GetContact(id); err != nil {
+			continue
+		}
+		group.AddMembers(id)
+	}
+	s.saveGroup(w, r, group)
+}
+
+// /groups/{id}
+func (s *Server) getGroupDetail(w http.ResponseWriter, r *http.Request) {
+	group, ok := s.groupFromPath(w, r)
+	if !ok {
+		return
+	}
+	render(w, r.Context(), views.GroupDetail(s.groupViewModel(group, views.GroupFormFromGroup(group))))
+}
+
+func (s *Server) groupViewModel(group models.Group, form *views.GroupForm) views.GroupViewModel {
+	members := s.groupMembers(group)
+	return views.GroupViewModel{
+		Group:       group,
+		Members:     members,
+		AddressList: export.AddressList(members),
+		Mailto:      export.MailtoURL(members),
+		Form:        form,
+	}
+}
+
+// contacts of the group in list order. members in the trash are skipped,
+// they show up again once restored.
+func (s *Server) groupMembers(group models.Group) []models.Contact {
+	var members []models.Contact
+	for _, id := range group.Members {
+		if contact, err := s.store.GetContact(id); err == nil {
+			members = append(members, contact)
+		}
+	}
+	return members
+}
+
+// /groups/{id}/edit
+func (s *Server) editGroup(w http.ResponseWriter, r *http.Request) {
+	group, ok := s.groupFromPath(w, r)
+	if !ok {
+		return
+	}
+	form := views.GroupFormFromRequest(r)
+	form.ID = group.ID
+	if !form.Valid() {
+		render(w, r.Context(), views.GroupDetail(s.groupViewModel(group, form)))
+		return
+	}
+	group.Name = form.Name
+	group.Description = form.Description
+	if err := s.groups.EditGroup(group); err != nil {
+		switch err {
+		case ErrDuplicateGroup:
+			form.Errors.Set(views.GroupFormName, err.Error())
+			render(w, r.Context(), views.GroupDetail(s.groupViewModel(group, form)))
+		case ErrGroupNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	redirect(w, r, fmt.Sprintf("/groups/%d", group.ID))
+}
+
+func (s *Server) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := s.groups.DeleteGroup(id); err != nil {
+		switch err {
+		case ErrGroupNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	render(w, r.Context(), views.Groups(views.GroupsViewModel{Groups: s.groups.Groups(), Form: &views.GroupForm{}}))
+}
+
+// /groups/{id}/members/{contact}
+func (s *Server) removeGroupMember(w http.ResponseWriter, r *http.Request) {
+	group, ok := s.groupFromPath(w, r)
+	if !ok {
+		return
+	}
+	contactID, _ := strconv.Atoi(r.PathValue("contact"))
+	if !group.HasMember(contactID) {
+		http.NotFound(w, r)
+		return
+	}
+	group.RemoveMember(contactID)
+	if err := s.groups.EditGroup(group); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	renderString(w, "")
+}
+
+// /groups/{id}/members/{contact}/move with offset -1 moves a member up
+func (s *Server) moveGroupMember(w http.ResponseWriter, r *http.Request) {
+	group, ok := s.groupFromPath(w, r)
+	if !ok {
+		return
+	}
+	contactID, _ := strconv.Atoi(r.PathValue("contact"))
+	offset, _ := strconv.Atoi(r.PostFormValue("offset"))
+	group.MoveMember(contactID, offset)
+	s.saveGroup(w, r, group)
+}
+
+// /groups/{id}/export?format=csv, an RFC 5322 address list by default
+func (s *Server) exportGroup(w http.ResponseWriter, r *http.Request) {
+	group, ok := s.groupFromPath(w, r)
+	if !ok {
+		return
+	}
+	members := s.groupMembers(group)
+	switch r.URL.Query().Get("format") {
+	case "", "rfc5322":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="group.txt"`)
+		renderString(w, export.AddressList(members)+"\n")
+	case "mailto":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		renderString(w, export.MailtoURL(members)+"\n")
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="group.csv"`)
+		if err := export.WriteEmailCSV(w, members); err != nil {
+			log.Println(err)
+		}
+	default:
+		http.Error(w, "unknown export format", http.StatusBadRequest)
+	}
+}
+
+func (s *Server) groupFromPath(w http.ResponseWriter, r *http.Request) (models.Group, bool) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return models.Group{}, false
+	}
+	group, err := s.groups.GetGroup(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return models.Group{}, false
+	}
+	return group, true
+}
+
+// saveGroup stores changed members and goes back to the group page
+func (s *Server) saveGroup(w http.ResponseWriter, r *http.Request, group models.Group) {
+	if err := s.groups.EditGroup(group); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	redirect(w, r, fmt.Sprintf("/groups/%d", group.ID))
+}
@@ -0,0 +1,87 @@
+package contactapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	store := NewinMemoryStore()
+	groups := NewInMemoryGroupStore()
+	server := NewContactServer(store, WithGroupStore(groups))
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	post := func(path string, f url.Values) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+	members := func() []int {
+		group, _ := groups.GetGroup(1)
+		return group.Members
+	}
+
+	t.Run("create a group", func(t *testing.T) {
+		assertRedirect(t, post("/groups", url.Values{"name": {"Newsletter"}}), "/groups/1")
+		res := post("/groups", url.Values{"name": {"newsletter"}})
+		assertCode(t, res.Code, http.StatusOK)
+		if !strings.Contains(res.Body.String(), ErrDuplicateGroup.Error()) {
+			t.Errorf("expected names to be unique")
+		}
+	})
+
+	t.Run("contact list offers adding selected contacts", func(t *testing.T) {
+		body := serve(newGetRequest("/contacts")).Body.String()
+		if !strings.Contains(body, `formaction="/groups/members"`) || !strings.Contains(body, "Newsletter") {
+			t.Errorf("expected the group picker on the contact list")
+		}
+		res := post("/groups/members", url.Values{"group": {"1"}, "selected_id": {"2", "1", "2", "99"}})
+		assertRedirect(t, res, "/groups/1")
+		if got, want := members(), []int{2, 1}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got members %v, wanted %v", got, want)
+		}
+	})
+
+	t.Run("members can be reordered", func(t *testing.T) {
+		assertRedirect(t, post("/groups/1/members/1/move", url.Values{"offset": {"-1"}}), "/groups/1")
+		if got, want := members(), []int{1, 2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got members %v, wanted %v", got, want)
+		}
+	})
+
+	t.Run("export in list order", func(t *testing.T) {
+		res := serve(newGetRequest("/groups/1/export"))
+		want := `"Jack Jackson" <jack@jaskcons.com>, "John Doe" <john@doe.com>` + "\n"
+		if res.Body.String() != want {
+			t.Errorf("got %q, wanted %q", res.Body.String(), want)
+		}
+		res = serve(newGetRequest("/groups/1/export?format=csv"))
+		if want := "name,email\nJack Jackson,jack@jaskcons.com\nJohn Doe,john@doe.com\n"; res.Body.String() != want {
+			t.Errorf("got %q, wanted %q", res.Body.String(), want)
+		}
+		res = serve(newGetRequest("/groups/1/export?format=mailto"))
+		if want := "mailto:jack@jaskcons.com,john@doe.com\n"; res.Body.String() != want {
+			t.Errorf("got %q, wanted %q", res.Body.String(), want)
+		}
+	})
+
+	t.Run("remove a member and delete the group", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/groups/1/members/1", nil)
+		assertCode(t, serve(req).Code, http.StatusOK)
+		if got, want := members(), []int{2}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got members %v, wanted %v", got, want)
+		}
+		req, _ = http.NewRequest(http.MethodDelete, "/groups/1", nil)
+		assertCode(t, serve(req).Code, http.StatusOK)
+		assertCode(t, serve(newGetRequest("/groups/1")).Code, http.StatusNotFound)
+	})
+}
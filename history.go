@@ -0,0 +1,109 @@
+package contactapp
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+// recordRevision stores after as the newest revision of the contact. contacts
+// that predate revision history get before stored first so there is
+// something to revert to.
+func (s *Server) recordRevision(r *http.Request, before, after models.Contact, revertedFrom int) {
+	s.recordBaseline(before)
+	_, err := s.revisions.AddRevision(models.Revision{
+		ContactID:    after.ID,
+		Contact:      after,
+		Time:         time.Now(),
+		Author:       actor(r),
+		RevertedFrom: revertedFrom,
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// recordBaseline keeps a contact as it was before its first recorded change
+func (s *Server) recordBaseline(c models.Contact) {
+	if c.ID == 0 || len(s.revisions.Revisions(c.ID)) != 0 {
+		return
+	}
+	if _, err := s.revisions.AddRevision(models.Revision{
+		ContactID: c.ID,
+		Contact:   c,
+		Time:      time.Now(),
+	}); err != nil {
+		log.Println(err)
+	}
+}
+
+// /contacts/{id}/history
+func (s *Server) getContactHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	contact, err := s.store.GetContact(id)
+	if err != nil {
+		http.Error(w, "contact not found", http.StatusNotFound)
+		return
+	}
+	render(w, r.Context(), views.ContactHistory(s.historyViewModel(contact)))
+}
+
+func (s *Server) historyViewModel(contact models.Contact) views.HistoryViewModel {
+	return views.HistoryViewModel{
+		Contact:   contact,
+		Revisions: s.revisions.Revisions(contact.ID),
+	}
+}
+
+// /contacts/{id}/history/{rev}/revert
+func (s *Server) revertContact(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	number, err := strconv.Atoi(r.PathValue("rev"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	revision, err := s.revisions.Revision(id, number)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	before, err := s.store.GetContact(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	after := revision.Contact
+	after.ID, after.Version = id, before.Version
+	if err := s.store.EditContact(after); err != nil {
+		switch err {
+		case ErrDuplicateEmail, ErrEditConflict:
+			model := s.historyViewModel(before)
+			model.Error = fmt.Sprintf("can't revert to revision %d: %s", number, err)
+			w.WriteHeader(http.StatusConflict)
+			render(w, r.Context(), views.ContactHistory(model))
+		case ErrNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	s.recordChange(r, audit.ActionRevert, before, after)
+	s.recordRevision(r, before, after, number)
+	redirect(w, r, fmt.Sprintf("/contacts/%d/history", id))
+}
@@ -0,0 +1,130 @@
+package contactapp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestContactHistory(t *testing.T) {
+	store := NewinMemoryStore()
+	revisions := NewInMemoryRevisionStore()
+	server := NewContactServer(store, WithRevisionStore(revisions))
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	revert := func(id, rev int) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/contacts/%d/history/%d/revert", id, rev), nil)
+		return serve(req)
+	}
+
+	// what a contact holds, leaving out what the store keeps track of
+	values := func(c models.Contact) models.Contact {
+		c.UpdatedAt, c.Version = time.Time{}, 0
+		return c
+	}
+	original, _ := store.GetContact(1)
+	edited := original
+	edited.Emails = emails("jack@new.com")
+	edited.Phones = phones("(415) 555-0155")
+
+	t.Run("first edit keeps the original as a baseline revision", func(t *testing.T) {
+		res := serve(editContactRequest(edited))
+		assertRedirect(t, res, "/contacts/1")
+
+		got := revisions.Revisions(1)
+		if len(got) != 2 {
+			t.Fatalf("got %d revisions, wanted 2", len(got))
+		}
+		if !reflect.DeepEqual(got[0].Contact, original) || !reflect.DeepEqual(got[1].Contact, edited) {
+			t.Errorf("got revisions %+v and %+v", got[0].Contact, got[1].Contact)
+		}
+	})
+
+	t.Run("history page shows field diffs", func(t *testing.T) {
+		res := serve(newGetRequest("/contacts/1/history"))
+		assertCode(t, res.Code, http.StatusOK)
+		body := res.Body.String()
+		for _, want := range []string{"Revision 2", "<del>home: " + original.PrimaryEmail() + "</del>", "<ins>home: jack@new.com</ins>", "/contacts/1/history/1/revert"} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected history page to contain %q", want)
+			}
+		}
+		if strings.Contains(body, "/contacts/1/history/2/revert") {
+			t.Errorf("current revision shouldn't offer a revert")
+		}
+	})
+
+	t.Run("revert restores values as a new revision", func(t *testing.T) {
+		res := revert(1, 1)
+		assertRedirect(t, res, "/contacts/1/history")
+
+		current, _ := store.GetContact(1)
+		if !reflect.DeepEqual(values(current), original) {
+			t.Errorf("got %+v after revert, wanted %+v", current, original)
+		}
+		got := revisions.Revisions(1)
+		if len(got) != 3 || got[2].RevertedFrom != 1 || !reflect.DeepEqual(values(got[2].Contact), original) {
+			t.Errorf("expected a third revision reverting to 1, got %+v", got)
+		}
+	})
+
+	t.Run("revert respects duplicate email rule", func(t *testing.T) {
+		// give contact 2 the email contact 1 had in revision 2
+		other, _ := store.GetContact(2)
+		other.Emails = emails("jack@new.com")
+		serve(editContactRequest(other))
+
+		before, _ := store.GetContact(1)
+		res := revert(1, 2)
+		assertCode(t, res.Code, http.StatusConflict)
+		if !strings.Contains(res.Body.String(), ErrDuplicateEmail.Error()) {
+			t.Errorf("expected duplicate email error on the page")
+		}
+		if current, _ := store.GetContact(1); !reflect.DeepEqual(current, before) {
+			t.Errorf("contact changed by a rejected revert: %+v", current)
+		}
+		if got := len(revisions.Revisions(1)); got != 3 {
+			t.Errorf("got %d revisions after rejected revert, wanted 3", got)
+		}
+	})
+
+	t.Run("new contact starts at revision 1", func(t *testing.T) {
+		serve(newContactRequest(models.Contact{FirstName: "A", LastName: "B", Phones: phones("(415) 555-0101"), Emails: emails("a@b.com")}))
+		got := revisions.Revisions(4)
+		if len(got) != 1 || got[0].Number != 1 {
+			t.Errorf("got %+v, wanted a single revision", got)
+		}
+	})
+
+	t.Run("unknown revision is 404", func(t *testing.T) {
+		assertCode(t, revert(1, 42).Code, http.StatusNotFound)
+		assertCode(t, revert(3, 1).Code, http.StatusNotFound)
+	})
+}
+
+func TestRevertEditConflict(t *testing.T) {
+	contact := models.Contact{ID: 1, FirstName: "Chris", LastName: "Jackson", Version: 2}
+	store := &StubContactStore{contacts: []models.Contact{contact}, editErr: ErrEditConflict}
+	revisions := NewInMemoryRevisionStore()
+	revisions.AddRevision(models.Revision{ContactID: 1, Contact: contact})
+	server := NewContactServer(store, WithRevisionStore(revisions))
+
+	req, _ := http.NewRequest(http.MethodPost, "/contacts/1/history/1/revert", nil)
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, withSession(req, signedIn(server)))
+	assertCode(t, res.Code, http.StatusConflict)
+	if !strings.Contains(res.Body.String(), ErrEditConflict.Error()) {
+		t.Errorf("expected the conflict on the history page")
+	}
+}
@@ -0,0 +1,59 @@
+package contactapp
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+var ErrFieldNotFound = errors.New("field not found")
+
+// InMemoryFieldSchema keeps the custom fields of the address book in the
+// order they were added
+type InMemoryFieldSchema struct {
+	mu     sync.RWMutex
+	fields []models.FieldDefinition
+}
+
+func NewInMemoryFieldSchema(fields ...models.FieldDefinition) *InMemoryFieldSchema {
+	return &InMemoryFieldSchema{fields: fields}
+}
+
+func (s *InMemoryFieldSchema) Fields() []models.FieldDefinition {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.FieldDefinition(nil), s.fields...)
+}
+
+// SaveField adds field, replacing the one with the same key if there is one
+func (s *InMemoryFieldSchema) SaveField(field models.FieldDefinition) error {
+	if err := field.Check(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.fields {
+		if s.fields[i].Key == field.Key {
+			s.fields[i] = field
+			return nil
+		}
+	}
+	s.fields = append(s.fields, field)
+	return nil
+}
+
+// DeleteField removes the definition. contacts lose their values of the
+// field the next time they are saved, the contact form drops values of
+// fields that aren't defined.
+func (s *InMemoryFieldSchema) DeleteField(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.fields {
+		if s.fields[i].Key == key {
+			s.fields = append(s.fields[:i], s.fields[i+1:]...)
+			return nil
+		}
+	}
+	return ErrFieldNotFound
+}
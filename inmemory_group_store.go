@@ -0,0 +1,115 @@
+package contactapp
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+var (
+	ErrGroupNotFound  = errors.New("group not found")
+	ErrDuplicateGroup = errors.New("group name is taken")
+)
+
+type InMemoryGroupStore struct {
+	mu     sync.RWMutex
+	groups []models.Group
+	idSeq  int
+}
+
+func NewInMemoryGroupStore() *InMemoryGroupStore {
+	return &InMemoryGroupStore{}
+}
+
+// by name
+func (s *InMemoryGroupStore) Groups() []models.Group {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	groups := make([]models.Group, 0, len(s.groups))
+	for _, group := range s.groups {
+		groups = append(groups, cloneGroup(group))
+	}
+	slices.SortFunc(groups, func(a, b models.Group) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return groups
+}
+
+func (s *InMemoryGroupStore) GetGroup(id int) (models.Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, group := range s.groups {
+		if group.ID == id {
+			return cloneGroup(group), nil
+		}
+	}
+	return models.Group{}, ErrGroupNotFound
+}
+
+func (s *InMemoryGroupStore) AddGroup(group models.Group) (models.Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nameTaken(group.Name, 0) {
+		return models.Group{}, ErrDuplicateGroup
+	}
+	s.idSeq++
+	group.ID = s.idSeq
+	s.groups = append(s.groups, cloneGroup(group))
+	return group, nil
+}
+
+func (s *InMemoryGroupStore) EditGroup(group models.Group) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.nameTaken(group.Name, group.ID) {
+		return ErrDuplicateGroup
+	}
+	for i := range s.groups {
+		if s.groups[i].ID == group.ID {
+			s.groups[i] = cloneGroup(group)
+			return nil
+		}
+	}
+	return ErrGroupNotFound
+}
+
+func (s *InMemoryGroupStore) DeleteGroup(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.groups {
+		if s.groups[i].ID == id {
+			s.groups = slices.Delete(s.groups, i, i+1)
+			return nil
+		}
+	}
+	return ErrGroupNotFound
+}
+
+// RemoveContactFromGroups drops a contact from every group it is a member
+// of, for when it is gone for good
+func (s *InMemoryGroupStore) RemoveContactFromGroups(contactID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.groups {
+		s.groups[i].RemoveMember(contactID)
+	}
+}
+
+// group names are compared case-insensitively
+func (s *InMemoryGroupStore) nameTaken(name string, id int) bool {
+	for _, group := range s.groups {
+		if group.ID != id && strings.EqualFold(group.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// callers get their own member list to change
+func cloneGroup(group models.Group) models.Group {
+	group.Members = slices.Clone(group.Members)
+	return group
+}
@@ -0,0 +1,79 @@
+package contactapp
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+var ErrInteractionNotFound = errors.New("interaction not found")
+
+type InMemoryInteractionStore struct {
+	mu           sync.RWMutex
+	interactions []models.Interaction
+	idSeq        int
+}
+
+func NewInMemoryInteractionStore() *InMemoryInteractionStore {
+	return &InMemoryInteractionStore{}
+}
+
+func (s *InMemoryInteractionStore) Interactions(contactID int) []models.Interaction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var interactions []models.Interaction
+	for _, i := range s.interactions {
+		if i.ContactID == contactID {
+			interactions = append(interactions, i)
+		}
+	}
+	slices.SortStableFunc(interactions, func(a, b models.Interaction) int {
+		return b.Time.Compare(a.Time)
+	})
+	return interactions
+}
+
+func (s *InMemoryInteractionStore) AddInteraction(interaction models.Interaction) (models.Interaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idSeq++
+	interaction.ID = s.idSeq
+	s.interactions = append(s.interactions, interaction)
+	return interaction, nil
+}
+
+func (s *InMemoryInteractionStore) DeleteInteraction(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for idx, i := range s.interactions {
+		if i.ID == id {
+			s.interactions = slices.Delete(s.interactions, idx, idx+1)
+			return nil
+		}
+	}
+	return ErrInteractionNotFound
+}
+
+// DeleteContactInteractions drops the timeline of a contact that is gone for
+// good
+func (s *InMemoryInteractionStore) DeleteContactInteractions(contactID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interactions = slices.DeleteFunc(s.interactions, func(i models.Interaction) bool {
+		return i.ContactID == contactID
+	})
+}
+
+// MoveContactInteractions hands the timeline of a contact to another, for
+// when it is merged into it
+func (s *InMemoryInteractionStore) MoveContactInteractions(from, to int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for idx, i := range s.interactions {
+		if i.ContactID == from {
+			s.interactions[idx].ContactID = to
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package contactapp
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+var (
+	ErrRelationshipNotFound  = errors.New("relationship not found")
+	ErrDuplicateRelationship = errors.New("relationship already exists")
+)
+
+type InMemoryRelationshipStore struct {
+	mu            sync.RWMutex
+	relationships []models.Relationship
+	idSeq         int
+}
+
+func NewInMemoryRelationshipStore() *InMemoryRelationshipStore {
+	return &InMemoryRelationshipStore{}
+}
+
+// Relationships of a contact: its own and the mutual ones pointing at it
+func (s *InMemoryRelationshipStore) Relationships(contactID int) []models.Relationship {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var relationships []models.Relationship
+	for _, r := range s.relationships {
+		if r.ContactID == contactID || (r.Mutual && r.RelatedID == contactID) {
+			relationships = append(relationships, r)
+		}
+	}
+	return relationships
+}
+
+func (s *InMemoryRelationshipStore) AddRelationship(relationship models.Relationship) (models.Relationship, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.relationships {
+		if r.ContactID == relationship.ContactID && r.RelatedID == relationship.RelatedID && r.Type == relationship.Type {
+			return models.Relationship{}, ErrDuplicateRelationship
+		}
+	}
+	s.idSeq++
+	relationship.ID = s.idSeq
+	s.relationships = append(s.relationships, relationship)
+	return relationship, nil
+}
+
+func (s *InMemoryRelationshipStore) DeleteRelationship(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, r := range s.relationships {
+		if r.ID == id {
+			s.relationships = slices.Delete(s.relationships, i, i+1)
+			return nil
+		}
+	}
+	return ErrRelationshipNotFound
+}
+
+// DeleteContactRelationships drops every relationship on either side of a
+// contact, for when it is gone for good
+func (s *InMemoryRelationshipStore) DeleteContactRelationships(contactID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.relationships = slices.DeleteFunc(s.relationships, func(r models.Relationship) bool {
+		return r.Involves(contactID)
+	})
+}
+
+// MoveContactRelationships hands the relationships of a contact to another,
+// for when it is merged into it. relationships that would relate the
+// contact to itself, or that it has already, are dropped.
+func (s *InMemoryRelationshipStore) MoveContactRelationships(from, to int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var relationships []models.Relationship
+	for _, r := range s.relationships {
+		if r.ContactID == from {
+			r.ContactID = to
+		}
+		if r.RelatedID == from {
+			r.RelatedID = to
+		}
+		duplicate := slices.ContainsFunc(relationships, func(other models.Relationship) bool {
+			return other.ContactID == r.ContactID && other.RelatedID == r.RelatedID && other.Type == r.Type
+		})
+		if r.ContactID == r.RelatedID || duplicate {
+			continue
+		}
+		relationships = append(relationships, r)
+	}
+	s.relationships = relationships
+}
@@ -0,0 +1,122 @@
+package contactapp
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+var ErrReminderNotFound = errors.New("reminder not found")
+
+type InMemoryReminderStore struct {
+	mu        sync.RWMutex
+	reminders []models.Reminder
+	idSeq     int
+}
+
+func NewInMemoryReminderStore() *InMemoryReminderStore {
+	return &InMemoryReminderStore{}
+}
+
+func (s *InMemoryReminderStore) find(by func(models.Reminder) bool) []models.Reminder {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var reminders []models.Reminder
+	for _, r := range s.reminders {
+		if by(r) {
+			reminders = append(reminders, r)
+		}
+	}
+	slices.SortStableFunc(reminders, func(a, b models.Reminder) int {
+		return a.Due.Compare(b.Due)
+	})
+	return reminders
+}
+
+func (s *InMemoryReminderStore) Reminders(contactID int) []models.Reminder {
+	return s.find(func(r models.Reminder) bool { return r.ContactID == contactID })
+}
+
+func (s *InMemoryReminderStore) OpenReminders() []models.Reminder {
+	return s.find(func(r models.Reminder) bool { return !r.Done })
+}
+
+func (s *InMemoryReminderStore) GetReminder(id int) (models.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.reminders {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return models.Reminder{}, ErrReminderNotFound
+}
+
+func (s *InMemoryReminderStore) AddReminder(reminder models.Reminder) (models.Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idSeq++
+	reminder.ID = s.idSeq
+	s.reminders = append(s.reminders, reminder)
+	return reminder, nil
+}
+
+func (s *InMemoryReminderStore) EditReminder(reminder models.Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for idx, r := range s.reminders {
+		if r.ID == reminder.ID {
+			s.reminders[idx] = reminder
+			return nil
+		}
+	}
+	return ErrReminderNotFound
+}
+
+func (s *InMemoryReminderStore) MarkNotified(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for idx, r := range s.reminders {
+		if r.ID == id {
+			s.reminders[idx].Notified = true
+			return nil
+		}
+	}
+	return ErrReminderNotFound
+}
+
+func (s *InMemoryReminderStore) DeleteReminder(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for idx, r := range s.reminders {
+		if r.ID == id {
+			s.reminders = slices.Delete(s.reminders, idx, idx+1)
+			return nil
+		}
+	}
+	return ErrReminderNotFound
+}
+
+// DeleteContactReminders drops the reminders of a contact that is gone for
+// good
+func (s *InMemoryReminderStore) DeleteContactReminders(contactID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reminders = slices.DeleteFunc(s.reminders, func(r models.Reminder) bool {
+		return r.ContactID == contactID
+	})
+}
+
+// MoveContactReminders hands the reminders of a contact to another, for when
+// it is merged into it
+func (s *InMemoryReminderStore) MoveContactReminders(from, to int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for idx, r := range s.reminders {
+		if r.ContactID == from {
+			s.reminders[idx].ContactID = to
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package contactapp
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+type InMemoryRevisionStore struct {
+	mu        sync.RWMutex
+	revisions map[int][]models.Revision
+}
+
+func NewInMemoryRevisionStore() *InMemoryRevisionStore {
+	return &InMemoryRevisionStore{revisions: make(map[int][]models.Revision)}
+}
+
+func (s *InMemoryRevisionStore) AddRevision(revision models.Revision) (models.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	revision.Number = len(s.revisions[revision.ContactID]) + 1
+	s.revisions[revision.ContactID] = append(s.revisions[revision.ContactID], revision)
+	return revision, nil
+}
+
+// oldest first
+func (s *InMemoryRevisionStore) Revisions(contactID int) []models.Revision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.Revision(nil), s.revisions[contactID]...)
+}
+
+func (s *InMemoryRevisionStore) Revision(contactID, number int) (models.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	revisions := s.revisions[contactID]
+	if number < 1 || number > len(revisions) {
+		return models.Revision{}, ErrRevisionNotFound
+	}
+	return revisions[number-1], nil
+}
+
+// MoveContactRevisions hands the revisions of a contact to another, for when
+// it is merged into it. they follow the revisions of the other contact and
+// remember which contact they came from.
+func (s *InMemoryRevisionStore) MoveContactRevisions(from, to int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, revision := range s.revisions[from] {
+		revision.ContactID = to
+		revision.Number = len(s.revisions[to]) + 1
+		if revision.MergedFrom == 0 {
+			revision.MergedFrom = from
+		}
+		s.revisions[to] = append(s.revisions[to], revision)
+	}
+	delete(s.revisions, from)
+}
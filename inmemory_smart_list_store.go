@@ -0,0 +1,74 @@
+package contactapp
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+var (
+	ErrSmartListNotFound  = errors.New("smart list not found")
+	ErrDuplicateSmartList = errors.New("smart list name is taken")
+)
+
+type InMemorySmartListStore struct {
+	mu    sync.RWMutex
+	lists []models.SmartList
+	idSeq int
+}
+
+func NewInMemorySmartListStore() *InMemorySmartListStore {
+	return &InMemorySmartListStore{}
+}
+
+// by name
+func (s *InMemorySmartListStore) SmartLists() []models.SmartList {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	lists := slices.Clone(s.lists)
+	slices.SortFunc(lists, func(a, b models.SmartList) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return lists
+}
+
+func (s *InMemorySmartListStore) GetSmartList(id int) (models.SmartList, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, list := range s.lists {
+		if list.ID == id {
+			return list, nil
+		}
+	}
+	return models.SmartList{}, ErrSmartListNotFound
+}
+
+func (s *InMemorySmartListStore) AddSmartList(list models.SmartList) (models.SmartList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, other := range s.lists {
+		// names are compared case-insensitively
+		if strings.EqualFold(other.Name, list.Name) {
+			return models.SmartList{}, ErrDuplicateSmartList
+		}
+	}
+	s.idSeq++
+	list.ID = s.idSeq
+	s.lists = append(s.lists, list)
+	return list, nil
+}
+
+func (s *InMemorySmartListStore) DeleteSmartList(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.lists {
+		if s.lists[i].ID == id {
+			s.lists = slices.Delete(s.lists, i, i+1)
+			return nil
+		}
+	}
+	return ErrSmartListNotFound
+}
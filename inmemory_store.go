@@ -2,69 +2,110 @@ package contactapp
 
 import (
 	"errors"
-	"math"
-	"strings"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/search"
 )
 
 type InMemoryStore struct {
+	mu       sync.RWMutex
 	contacts []models.Contact
 	idSeq    int
+	// contacts not in the trash, for searching
+	index *search.Index
 }
 
 func (s *InMemoryStore) get(by func(c models.Contact) bool) *models.Contact {
-	for _, contact := range s.contacts {
+	for _, contact := range s.live() {
 		if by(contact) {
 			return &contact
 		}
 	}
 	return nil
 }
+
+// contacts that aren't in the trash
+func (s *InMemoryStore) live() []models.Contact {
+	var contacts []models.Contact
+	for _, contact := range s.contacts {
+		if !contact.Deleted() {
+			contacts = append(contacts, contact)
+		}
+	}
+	return contacts
+}
 func (s *InMemoryStore) nextId() int {
 	s.idSeq++
 	return s.idSeq
 }
 
-func totalPage(total int) int {
-	return int(math.Ceil(float64(total) / float64(10)))
-}
-
-func paged(s []models.Contact, page int) []models.Contact {
-	var contacts []models.Contact
-	start := (page - 1) * 10
-	for idx := start; idx < len(s) && len(contacts) != 10; idx++ {
-		contacts = append(contacts, s[idx])
-	}
-	return contacts
+func (s *InMemoryStore) GetContacts(page models.Page) models.ContactPage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return models.PageOf(s.live(), models.Sort{}, false, page)
 }
 
-func (s *InMemoryStore) GetContacts(page int) ([]models.Contact, int) {
-	return paged(s.contacts, page), totalPage(len(s.contacts))
+func (s *InMemoryStore) AllContacts() []models.Contact {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.live()
 }
 
-func (s *InMemoryStore) FilterContacts(q string, page int) ([]models.Contact, int) {
+// FilterContacts ranks the contacts by relevance when searching, unless the
+// filter sorts them
+func (s *InMemoryStore) FilterContacts(filter models.ContactFilter, page models.Page) models.ContactPage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	var contacts []models.Contact
-	for _, contact := range s.contacts {
-		if strings.Contains(contact.FirstName, q) || strings.Contains(contact.LastName, q) {
+	if len(models.QueryTerms(filter.Query)) == 0 {
+		for _, contact := range s.live() {
+			if filter.Matches(contact) {
+				contacts = append(contacts, contact)
+			}
+		}
+		models.SortContacts(contacts, filter.Sort)
+		return models.PageOf(contacts, filter.Sort, false, page)
+	}
+	live := make(map[int]models.Contact)
+	for _, contact := range s.live() {
+		live[contact.ID] = contact
+	}
+	for _, result := range s.index.Search(filter.Query) {
+		if contact, ok := live[result.ID]; ok && filter.Matches(contact) {
 			contacts = append(contacts, contact)
 		}
 	}
-	return paged(contacts, page), totalPage(len(contacts))
+	ranked := filter.Sort == models.Sort{}
+	models.SortContacts(contacts, filter.Sort)
+	return models.PageOf(contacts, filter.Sort, ranked, page)
+}
+
+func (s *InMemoryStore) SuggestQuery(query string) string {
+	return s.index.Suggest(query)
 }
 
-func (s *InMemoryStore) AddContact(contact models.Contact) error {
-	if s.DuplicateEmail(contact.Email, 0) {
-		return ErrDuplicateEmail
+func (s *InMemoryStore) AddContact(contact models.Contact) (models.Contact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.duplicateEmails(contact.Emails, 0) {
+		return models.Contact{}, ErrDuplicateEmail
 	}
 	contact.ID = s.nextId()
+	contact.CreatedAt = time.Now()
+	contact.UpdatedAt = contact.CreatedAt
+	contact.Version = 1
 	s.contacts = append(s.contacts, contact)
-	return nil
+	s.index.Add(contact)
+	return contact, nil
 }
 
 func (s *InMemoryStore) GetContact(id int) (models.Contact, error) {
-	for _, contact := range s.contacts {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, contact := range s.live() {
 		if contact.ID == id {
 			return contact, nil
 		}
@@ -73,23 +114,129 @@ func (s *InMemoryStore) GetContact(id int) (models.Contact, error) {
 }
 
 func (s *InMemoryStore) EditContact(contact models.Contact) error {
-	if s.DuplicateEmail(contact.Email, contact.ID) {
-		return ErrDuplicateEmail
-	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for idx, c := range s.contacts {
-		if c.ID == contact.ID {
+		if c.ID == contact.ID && !c.Deleted() {
+			if contact.Version != c.Version {
+				return ErrEditConflict
+			}
+			if s.duplicateEmails(contact.Emails, contact.ID) {
+				return ErrDuplicateEmail
+			}
+			contact.CreatedAt, contact.LastContacted = c.CreatedAt, c.LastContacted
+			contact.UpdatedAt = time.Now()
+			contact.Version = c.Version + 1
 			s.contacts[idx] = contact
+			s.index.Add(contact)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *InMemoryStore) SetLastContacted(id int, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for idx, contact := range s.contacts {
+		if contact.ID == id {
+			s.contacts[idx].LastContacted = t
 			return nil
 		}
 	}
 	return ErrNotFound
 }
 
+// DeleteContact moves the contact to the trash
 func (s *InMemoryStore) DeleteContact(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for idx, contact := range s.contacts {
+		if contact.ID == id && !contact.Deleted() {
+			s.contacts[idx].DeletedAt = time.Now()
+			s.index.Remove(id)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// most recently deleted first
+func (s *InMemoryStore) GetTrash() []models.Contact {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var contacts []models.Contact
+	for _, contact := range s.contacts {
+		if contact.Deleted() {
+			contacts = append(contacts, contact)
+		}
+	}
+	sort.SliceStable(contacts, func(i, j int) bool {
+		return contacts[i].DeletedAt.After(contacts[j].DeletedAt)
+	})
+	return contacts
+}
+
+func (s *InMemoryStore) EditTrashedContact(contact models.Contact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for idx, c := range s.contacts {
+		if c.ID == contact.ID && c.Deleted() {
+			if contact.Version != c.Version {
+				return ErrEditConflict
+			}
+			contact.CreatedAt, contact.LastContacted, contact.DeletedAt = c.CreatedAt, c.LastContacted, c.DeletedAt
+			contact.UpdatedAt = time.Now()
+			contact.Version = c.Version + 1
+			s.contacts[idx] = contact
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *InMemoryStore) RestoreContact(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for idx, contact := range s.contacts {
+		if contact.ID == id && contact.Deleted() {
+			if s.duplicateEmails(contact.Emails, id) {
+				return ErrDuplicateEmail
+			}
+			s.contacts[idx].DeletedAt = time.Time{}
+			s.index.Add(s.contacts[idx])
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// PurgeContact permanently removes a contact from the trash
+func (s *InMemoryStore) PurgeContact(id int) error {
+	return s.purge(func(c models.Contact) bool { return c.ID == id })
+}
+
+// PurgeDeletedBefore permanently removes everything trashed before t and
+// returns the removed contacts
+func (s *InMemoryStore) PurgeDeletedBefore(t time.Time) []models.Contact {
+	var purged []models.Contact
+	s.purge(func(c models.Contact) bool {
+		if c.DeletedAt.Before(t) {
+			purged = append(purged, c)
+			return true
+		}
+		return false
+	})
+	return purged
+}
+
+func (s *InMemoryStore) purge(by func(c models.Contact) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	var contacts []models.Contact
 	found := false
 	for _, contact := range s.contacts {
-		if contact.ID == id {
+		if contact.Deleted() && by(contact) {
 			found = true
 			continue
 		}
@@ -100,15 +247,28 @@ func (s *InMemoryStore) DeleteContact(id int) error {
 	}
 	s.contacts = contacts
 	return nil
-
 }
 
+// DuplicateEmail reports whether any of the emails of another contact than
+// id is email
 func (s *InMemoryStore) DuplicateEmail(email string, id int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.duplicateEmail(email, id)
+}
+
+func (s *InMemoryStore) duplicateEmail(email string, id int) bool {
 	contactWithSameEmail := s.get(func(c models.Contact) bool {
-		return c.Email == email
+		return c.ID != id && c.HasEmail(email)
 	})
-	if contactWithSameEmail != nil && contactWithSameEmail.ID != id {
-		return true
+	return contactWithSameEmail != nil
+}
+
+func (s *InMemoryStore) duplicateEmails(emails []models.Email, id int) bool {
+	for _, email := range emails {
+		if s.duplicateEmail(email.Address, id) {
+			return true
+		}
 	}
 	return false
 }
@@ -116,18 +276,50 @@ func (s *InMemoryStore) DuplicateEmail(email string, id int) bool {
 // expensive call WOWO
 func (s *InMemoryStore) Count() int {
 	time.Sleep(time.Second * 5)
-	return len(s.contacts)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.live())
 }
 
-func NewinMemoryStore() *InMemoryStore {
+// InMemoryStoreOption configures an InMemoryStore
+type InMemoryStoreOption func(*InMemoryStore)
 
-	return &InMemoryStore{
+// WithMinSimilarity sets how close, from 0 to 1, a word has to be to a
+// misspelled one for search to suggest it instead, see
+// search.DefaultMinSimilarity
+func WithMinSimilarity(min float64) InMemoryStoreOption {
+	return func(s *InMemoryStore) {
+		s.index.MinSimilarity = min
+	}
+}
+
+func NewinMemoryStore(options ...InMemoryStoreOption) *InMemoryStore {
+	store := &InMemoryStore{
 		contacts: []models.Contact{
-			{ID: 1, FirstName: "Jack", LastName: "Jackson", Email: "jack@jaskcons.com", PhoneNumber: "213214"},
-			{ID: 2, FirstName: "John", LastName: "Doe", Email: "john@doe.com", PhoneNumber: "123142"},
-			{ID: 3, FirstName: "Arthur", LastName: "Morgan", Email: "artur@morgan.com", PhoneNumber: "213214"},
+			{
+				ID: 1, FirstName: "Jack", LastName: "Jackson",
+				Emails: []models.Email{{Label: "home", Address: "jack@jaskcons.com"}},
+				Phones: []models.Phone{{Label: "mobile", Number: "(415) 555-0132", E164: "+14155550132"}},
+			},
+			{
+				ID: 2, FirstName: "John", LastName: "Doe",
+				Emails: []models.Email{{Label: "home", Address: "john@doe.com"}},
+				Phones: []models.Phone{{Label: "mobile", Number: "(212) 555-0187", E164: "+12125550187"}},
+			},
+			{
+				ID: 3, FirstName: "Arthur", LastName: "Morgan",
+				Emails: []models.Email{{Label: "home", Address: "artur@morgan.com"}},
+				Phones: []models.Phone{{Label: "mobile", Number: "+44 7400 123456", E164: "+447400123456"}},
+			},
 		},
-		idSeq: 1,
+		idSeq: 3,
+		index: search.NewIndex(),
 	}
-
+	for _, option := range options {
+		option(store)
+	}
+	for _, contact := range store.contacts {
+		store.index.Add(contact)
+	}
+	return store
 }
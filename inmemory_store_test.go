@@ -0,0 +1,250 @@
+package contactapp
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+var firstPage = models.Page{Number: 1, Size: defaultLimit}
+
+func TestInMemoryStoreEmails(t *testing.T) {
+	store := NewinMemoryStore()
+	jack, _ := store.GetContact(1)
+	jack.Emails = append(jack.Emails, models.Email{Label: "work", Address: "jack@work.com"})
+	if err := store.EditContact(jack); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("duplicate check covers every email of a contact", func(t *testing.T) {
+		if !store.DuplicateEmail("jack@work.com", 2) {
+			t.Errorf("expected secondary email of contact 1 to be taken")
+		}
+		if !store.DuplicateEmail("JACK@WORK.COM", 2) {
+			t.Errorf("expected email check to ignore case")
+		}
+		if store.DuplicateEmail("jack@work.com", 1) {
+			t.Errorf("contact's own email shouldn't count as duplicate")
+		}
+	})
+
+	t.Run("adding a contact with any taken email fails", func(t *testing.T) {
+		_, err := store.AddContact(models.Contact{
+			FirstName: "New",
+			Emails:    []models.Email{{Label: "home", Address: "new@x.com"}, {Label: "work", Address: "jack@work.com"}},
+		})
+		if err != ErrDuplicateEmail {
+			t.Errorf("got %v, wanted %v", err, ErrDuplicateEmail)
+		}
+	})
+
+	t.Run("editing to a taken secondary email fails", func(t *testing.T) {
+		john, _ := store.GetContact(2)
+		john.Emails = append(john.Emails, models.Email{Label: "work", Address: "jack@work.com"})
+		if err := store.EditContact(john); err != ErrDuplicateEmail {
+			t.Errorf("got %v, wanted %v", err, ErrDuplicateEmail)
+		}
+	})
+}
+
+func TestInMemoryStoreFilter(t *testing.T) {
+	store := NewinMemoryStore()
+	jack, _ := store.GetContact(1)
+	jack.Company = "Acme"
+	jack.Notes = "plays golf"
+	store.EditContact(jack)
+
+	for _, q := range []string{"Acme", "golf", "Jack"} {
+		contacts := store.FilterContacts(models.ContactFilter{Query: q}, firstPage).Contacts
+		if len(contacts) != 1 || contacts[0].ID != 1 {
+			t.Errorf("searching %q got %v, wanted contact 1", q, contacts)
+		}
+	}
+
+	jack, _ = store.GetContact(1)
+	jack.Tags = []string{"golf"}
+	store.EditContact(jack)
+	for filter, want := range map[models.ContactFilter]int{
+		{Tag: "golf"}:                1,
+		{Tag: "golf", Query: "Jack"}: 1,
+		{Tag: "golf", Query: "John"}: 0,
+		{Tag: "gol"}:                 0,
+	} {
+		if contacts := store.FilterContacts(filter, firstPage).Contacts; len(contacts) != want {
+			t.Errorf("filter %+v got %v, wanted %d contacts", filter, contacts, want)
+		}
+	}
+}
+
+func TestInMemoryStoreSearch(t *testing.T) {
+	store := NewinMemoryStore()
+	chloe, _ := store.AddContact(models.Contact{
+		FirstName: "Chloé", LastName: "Dupré",
+		Emails: []models.Email{{Label: "work", Address: "chloe@atelier.fr"}},
+		Phones: []models.Phone{{Label: "mobile", Number: "+33 (6) 12-34-56"}},
+		Notes:  "Met at the STRASSE café",
+	})
+
+	for q, want := range map[string]int{
+		"chloe":         chloe.ID,
+		"CHLOÉ":         chloe.ID,
+		"chloe dupre":   chloe.ID,
+		"dupré chloé":   chloe.ID,
+		"atelier.fr":    chloe.ID,
+		"336123456":     chloe.ID,
+		"6 12 34":       chloe.ID,
+		"cafe":          chloe.ID,
+		"straße":        chloe.ID,
+		"john@doe":      2,
+		"jack jackson":  1,
+		"jack morgan":   0,
+		"chloe nobody":  0,
+		"+33 6 99 99 9": 0,
+	} {
+		contacts := store.FilterContacts(models.ContactFilter{Query: q}, firstPage).Contacts
+		var got int
+		if len(contacts) == 1 {
+			got = contacts[0].ID
+		}
+		if (want == 0 && len(contacts) != 0) || (want != 0 && got != want) {
+			t.Errorf("searching %q got %v, wanted contact %d", q, contacts, want)
+		}
+	}
+}
+
+func TestInMemoryStoreSearchRanking(t *testing.T) {
+	store := NewinMemoryStore()
+	noted, _ := store.AddContact(models.Contact{FirstName: "Anna", Notes: "introduced by Morgan"})
+
+	contacts := store.FilterContacts(models.ContactFilter{Query: "morgan"}, firstPage).Contacts
+	if len(contacts) != 2 || contacts[0].ID != 3 || contacts[1].ID != noted.ID {
+		t.Errorf("expected the name match before the note match, got %v", contacts)
+	}
+
+	store.DeleteContact(3)
+	contacts = store.FilterContacts(models.ContactFilter{Query: "morgan"}, firstPage).Contacts
+	if len(contacts) != 1 || contacts[0].ID != noted.ID {
+		t.Errorf("expected trashed contacts to drop out of the index, got %v", contacts)
+	}
+	store.RestoreContact(3)
+	anna, _ := store.GetContact(noted.ID)
+	anna.Notes = ""
+	store.EditContact(anna)
+	contacts = store.FilterContacts(models.ContactFilter{Query: "morgan"}, firstPage).Contacts
+	if len(contacts) != 1 || contacts[0].ID != 3 {
+		t.Errorf("expected restores and edits to update the index, got %v", contacts)
+	}
+}
+
+func TestInMemoryStoreSort(t *testing.T) {
+	store := NewinMemoryStore()
+	// a tie with Jack Jackson on last name, edited last
+	store.AddContact(models.Contact{FirstName: "Ann", LastName: "jackson"})
+	john, _ := store.GetContact(2)
+	store.EditContact(john)
+	arthur, _ := store.GetContact(3)
+	arthur.FirstName = "Ärthur"
+	store.EditContact(arthur)
+
+	ids := func(sort models.Sort, query string) []int {
+		contacts := store.FilterContacts(models.ContactFilter{Query: query, Sort: sort}, firstPage).Contacts
+		var ids []int
+		for _, contact := range contacts {
+			ids = append(ids, contact.ID)
+		}
+		return ids
+	}
+	for _, test := range []struct {
+		sort  models.Sort
+		query string
+		want  []int
+	}{
+		{models.Sort{}, "", []int{1, 2, 3, 4}},
+		{models.Sort{Desc: true}, "", []int{4, 3, 2, 1}},
+		{models.Sort{Key: models.SortFirstName}, "", []int{4, 3, 1, 2}},
+		{models.Sort{Key: models.SortLastName}, "", []int{2, 1, 4, 3}},
+		{models.Sort{Key: models.SortLastName, Desc: true}, "", []int{3, 4, 1, 2}},
+		{models.Sort{Key: models.SortEmail}, "", []int{4, 3, 1, 2}},
+		{models.Sort{Key: models.SortUpdated, Desc: true}, "", []int{3, 2, 4, 1}},
+		{models.Sort{Key: models.SortCreated}, "", []int{1, 2, 3, 4}},
+		{models.Sort{Key: models.SortFirstName}, "jackson", []int{4, 1}},
+	} {
+		if got := ids(test.sort, test.query); !slices.Equal(got, test.want) {
+			t.Errorf("sorting %q by %+v got %v, wanted %v", test.query, test.sort, got, test.want)
+		}
+	}
+}
+
+func TestInMemoryStorePages(t *testing.T) {
+	store := NewinMemoryStore()
+	for _, name := range []string{"Bea", "Cal", "Dan", "Eve"} {
+		store.AddContact(models.Contact{FirstName: name})
+	}
+	ids := func(page models.ContactPage) []int {
+		var ids []int
+		for _, contact := range page.Contacts {
+			ids = append(ids, contact.ID)
+		}
+		return ids
+	}
+
+	t.Run("numbered pages of the requested size", func(t *testing.T) {
+		page := store.GetContacts(models.Page{Number: 3, Size: 3})
+		if got := ids(page); !slices.Equal(got, []int{7}) || page.TotalPages != 3 || page.Next != nil {
+			t.Errorf("got %v of %d pages, next %v", got, page.TotalPages, page.Next)
+		}
+	})
+
+	t.Run("cursors resume after the last row shown", func(t *testing.T) {
+		sort := models.Sort{Key: models.SortFirstName, Desc: true}
+		filter := models.ContactFilter{Sort: sort}
+		first := store.FilterContacts(filter, models.Page{Number: 1, Size: 3})
+		if got := ids(first); !slices.Equal(got, []int{2, 1, 7}) {
+			t.Fatalf("got first page %v", got)
+		}
+		// rows added and deleted before the cursor don't shift the next page
+		store.DeleteContact(2)
+		store.AddContact(models.Contact{FirstName: "Zed"})
+		next := store.FilterContacts(filter, models.Page{Size: 3, After: first.Next})
+		if got := ids(next); !slices.Equal(got, []int{6, 5, 4}) {
+			t.Errorf("got next page %v, wanted [6 5 4]", got)
+		}
+		last := store.FilterContacts(filter, models.Page{Size: 3, After: next.Next})
+		if got := ids(last); !slices.Equal(got, []int{3}) || last.Next != nil {
+			t.Errorf("got last page %v, next %v", got, last.Next)
+		}
+		store.RestoreContact(2)
+	})
+
+	t.Run("ranked search results resume after the last row shown", func(t *testing.T) {
+		filter := models.ContactFilter{Query: "j"}
+		first := store.FilterContacts(filter, models.Page{Number: 1, Size: 1})
+		next := store.FilterContacts(filter, models.Page{Size: 1, After: first.Next})
+		if len(first.Contacts) != 1 || len(next.Contacts) != 1 || first.Contacts[0].ID == next.Contacts[0].ID {
+			t.Errorf("got %v then %v", ids(first), ids(next))
+		}
+	})
+
+	t.Run("cursors are opaque tokens", func(t *testing.T) {
+		cursor := models.Cursor{Sort: models.Sort{Key: models.SortEmail}, Key: "a@b.c", ID: 4, Offset: 10}
+		if got, err := models.ParseCursor(cursor.String()); err != nil || got != cursor {
+			t.Errorf("got %+v, %v", got, err)
+		}
+		for _, token := range []string{"", "not a cursor", "e30"} {
+			if _, err := models.ParseCursor(token); err != models.ErrInvalidCursor {
+				t.Errorf("parsing %q got %v", token, err)
+			}
+		}
+	})
+}
+
+func TestInMemoryStoreMinSimilarity(t *testing.T) {
+	// one typo in eight letters, of a word that isn't a name
+	if got := NewinMemoryStore().SuggestQuery("jaskconz"); got != "jaskcons" {
+		t.Errorf("got suggestion %q, wanted jaskcons", got)
+	}
+	if got := NewinMemoryStore(WithMinSimilarity(0.9)).SuggestQuery("jaskconz"); got != "" {
+		t.Errorf("got suggestion %q from a strict store, wanted none", got)
+	}
+}
@@ -0,0 +1,74 @@
+package contactapp
+
+import (
+	"crypto/subtle"
+	"strings"
+	"sync"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+type InMemoryUserStore struct {
+	mu    sync.RWMutex
+	users []models.User
+	idSeq int
+}
+
+func NewInMemoryUserStore() *InMemoryUserStore {
+	return &InMemoryUserStore{}
+}
+
+func (s *InMemoryUserStore) find(by func(u models.User) bool) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, user := range s.users {
+		if by(user) {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrUserNotFound
+}
+
+func (s *InMemoryUserStore) GetUser(id int) (models.User, error) {
+	return s.find(func(u models.User) bool { return u.ID == id })
+}
+
+func (s *InMemoryUserStore) GetUserByEmail(email string) (models.User, error) {
+	return s.find(func(u models.User) bool { return u.Email != "" && strings.EqualFold(u.Email, email) })
+}
+
+func (s *InMemoryUserStore) GetUserBySubject(issuer, subject string) (models.User, error) {
+	return s.find(func(u models.User) bool { return u.OIDCIssuer == issuer && u.OIDCSubject == subject })
+}
+
+func (s *InMemoryUserStore) GetUserByCalendarToken(token string) (models.User, error) {
+	return s.find(func(u models.User) bool {
+		return u.CalendarToken != "" && subtle.ConstantTimeCompare([]byte(u.CalendarToken), []byte(token)) == 1
+	})
+}
+
+func (s *InMemoryUserStore) AddUser(user models.User) (models.User, error) {
+	if user.Email != "" {
+		if _, err := s.GetUserByEmail(user.Email); err == nil {
+			return models.User{}, ErrDuplicateEmail
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idSeq++
+	user.ID = s.idSeq
+	s.users = append(s.users, user)
+	return user, nil
+}
+
+func (s *InMemoryUserStore) EditUser(user models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for idx, u := range s.users {
+		if u.ID == user.ID {
+			s.users[idx] = user
+			return nil
+		}
+	}
+	return ErrUserNotFound
+}
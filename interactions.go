@@ -0,0 +1,107 @@
+package contactapp
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+// /contacts/{id}/interactions is the timeline fragment of the detail page
+func (s *Server) getInteractions(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if _, err := s.store.GetContact(id); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.renderTimeline(w, r, id, views.NewInteractionForm())
+}
+
+func (s *Server) renderTimeline(w http.ResponseWriter, r *http.Request, contactID int, form *views.InteractionForm) {
+	renderPartial(w, r.Context(), views.Timeline(views.TimelineViewModel{
+		ContactID:    contactID,
+		Interactions: s.interactions.Interactions(contactID),
+		Form:         form,
+	}))
+}
+
+// /contacts/{id}/interactions
+func (s *Server) addInteraction(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if _, err := s.store.GetContact(id); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	form := views.InteractionFormFromRequest(r)
+	if !form.Valid() {
+		s.renderTimeline(w, r, id, form)
+		return
+	}
+	interaction := form.ToInteraction()
+	interaction.ContactID = id
+	interaction.Author = actor(r)
+	if _, err := s.interactions.AddInteraction(interaction); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	s.updateLastContacted(id)
+	if interaction.HasFollowUp() {
+		_, err := s.reminders.AddReminder(models.Reminder{
+			ContactID: id,
+			Due:       interaction.FollowUp,
+			Note:      "Follow up: " + interaction.Summary,
+		})
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	s.renderTimeline(w, r, id, views.NewInteractionForm())
+}
+
+// /contacts/{id}/interactions/{interaction}
+func (s *Server) deleteInteraction(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	interactionID, _ := strconv.Atoi(r.PathValue("interaction"))
+	found := false
+	for _, interaction := range s.interactions.Interactions(id) {
+		found = found || interaction.ID == interactionID
+	}
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.interactions.DeleteInteraction(interactionID); err != nil {
+		switch err {
+		case ErrInteractionNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	s.updateLastContacted(id)
+	renderString(w, "")
+}
+
+// updateLastContacted keeps the contact's LastContacted in line with its
+// timeline, which is what the contact list shows and filters on
+func (s *Server) updateLastContacted(contactID int) {
+	last := models.LastContacted(s.interactions.Interactions(contactID))
+	if err := s.store.SetLastContacted(contactID, last); err != nil {
+		log.Println(err)
+	}
+}
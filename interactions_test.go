@@ -0,0 +1,118 @@
+package contactapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/views"
+)
+
+func TestInteractions(t *testing.T) {
+	store := NewinMemoryStore()
+	interactions := NewInMemoryInteractionStore()
+	server := NewContactServer(store, WithInteractionStore(interactions))
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	log := func(id string, when time.Time, summary string) *httptest.ResponseRecorder {
+		f := url.Values{
+			views.InteractionFormType:    {"call"},
+			views.InteractionFormTime:    {when.Format("2006-01-02T15:04")},
+			views.InteractionFormSummary: {summary},
+		}
+		req, _ := http.NewRequest(http.MethodPost, "/contacts/"+id+"/interactions", strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+	list := func(query string) string {
+		return serve(newGetRequest("/contacts?" + query)).Body.String()
+	}
+
+	t.Run("detail page loads the timeline lazily", func(t *testing.T) {
+		body := serve(newGetRequest("/contacts/1")).Body.String()
+		if !strings.Contains(body, `hx-get="/contacts/1/interactions"`) {
+			t.Errorf("expected the timeline to be loaded from the detail page")
+		}
+		res := serve(newGetRequest("/contacts/1/interactions"))
+		assertCode(t, res.Code, http.StatusOK)
+		if !strings.Contains(res.Body.String(), "Nothing logged yet") {
+			t.Errorf("expected an empty timeline, got %s", res.Body.String())
+		}
+	})
+
+	t.Run("logging an interaction updates last contacted", func(t *testing.T) {
+		when := time.Now().AddDate(0, 0, -3)
+		res := log("1", when, "talked about the offer")
+		if !strings.Contains(res.Body.String(), "talked about the offer") {
+			t.Errorf("expected the new interaction in the timeline")
+		}
+		contact, _ := store.GetContact(1)
+		if !contact.Contacted() || contact.LastContacted.Format("2006-01-02") != when.Format("2006-01-02") {
+			t.Errorf("expected contact 1 to be last contacted at %v, got %v", when, contact.LastContacted)
+		}
+		if !strings.Contains(list(""), when.Format("Jan 2, 2006")) {
+			t.Errorf("expected last contacted in the contact list")
+		}
+	})
+
+	t.Run("editing keeps last contacted", func(t *testing.T) {
+		contact, _ := store.GetContact(1)
+		contact.LastContacted = time.Time{}
+		store.EditContact(contact)
+		if contact, _ := store.GetContact(1); !contact.Contacted() {
+			t.Errorf("expected last contacted to survive an edit")
+		}
+	})
+
+	t.Run("invalid interactions are rejected", func(t *testing.T) {
+		res := log("1", time.Now().Add(time.Hour*48), "")
+		if !strings.Contains(res.Body.String(), "can&#39;t be in the future") {
+			t.Errorf("expected an error about the time, got %s", res.Body.String())
+		}
+		if len(interactions.Interactions(1)) != 1 {
+			t.Errorf("expected no new interaction")
+		}
+	})
+
+	t.Run("filters contacts not touched in N days", func(t *testing.T) {
+		log("2", time.Now().AddDate(0, 0, -60), "old news")
+		body := list("untouched=30")
+		if strings.Contains(body, "Jackson") || !strings.Contains(body, "Doe") || !strings.Contains(body, "Morgan") {
+			t.Errorf("expected contacts 2 and 3 only, got %s", body)
+		}
+		body = list("untouched=90")
+		if strings.Contains(body, "Jackson") || strings.Contains(body, "Doe") || !strings.Contains(body, "Morgan") {
+			t.Errorf("expected contact 3 only")
+		}
+	})
+
+	t.Run("deleting an interaction recomputes last contacted", func(t *testing.T) {
+		id := interactions.Interactions(2)[0].ID
+		req, _ := http.NewRequest(http.MethodDelete, "/contacts/1/interactions/"+strconv.Itoa(id), nil)
+		assertCode(t, serve(req).Code, http.StatusNotFound)
+		req, _ = http.NewRequest(http.MethodDelete, "/contacts/2/interactions/"+strconv.Itoa(id), nil)
+		assertCode(t, serve(req).Code, http.StatusOK)
+		if contact, _ := store.GetContact(2); contact.Contacted() {
+			t.Errorf("expected contact 2 to be never contacted again")
+		}
+	})
+
+	t.Run("purging a contact drops its timeline", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/contacts/1", nil)
+		serve(req)
+		req, _ = http.NewRequest(http.MethodDelete, "/contacts/1/purge", nil)
+		serve(req)
+		if len(interactions.Interactions(1)) != 0 {
+			t.Errorf("expected the timeline of the purged contact to be gone")
+		}
+	})
+}
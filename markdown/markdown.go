@@ -0,0 +1,144 @@
+// Package markdown renders a small, safe subset of Markdown for contact notes.
+// all input is html-escaped before formatting is applied, so raw html in the
+// source is shown as text and never reaches the page.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	heading     = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletItem  = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedItem = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	quote       = regexp.MustCompile(`^>\s?(.*)$`)
+
+	codeSpan = regexp.MustCompile("`([^`]+)`")
+	link     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	bold     = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	italic   = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+)
+
+var allowedSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+type renderer struct {
+	out       strings.Builder
+	paragraph []string
+	list      string
+	inCode    bool
+}
+
+// Render converts src to sanitized html
+func Render(src string) string {
+	r := &renderer{}
+	for _, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		r.line(line)
+	}
+	if r.inCode {
+		r.out.WriteString("</code></pre>\n")
+	}
+	r.flush()
+	return r.out.String()
+}
+
+func (r *renderer) line(line string) {
+	if strings.HasPrefix(strings.TrimSpace(line), "```") {
+		if r.inCode {
+			r.out.WriteString("</code></pre>\n")
+		} else {
+			r.flush()
+			r.out.WriteString("<pre><code>")
+		}
+		r.inCode = !r.inCode
+		return
+	}
+	if r.inCode {
+		r.out.WriteString(html.EscapeString(line) + "\n")
+		return
+	}
+
+	switch {
+	case strings.TrimSpace(line) == "":
+		r.flush()
+	case heading.MatchString(line):
+		r.flush()
+		m := heading.FindStringSubmatch(line)
+		fmt.Fprintf(&r.out, "<h%d>%s</h%d>\n", len(m[1]), inline(m[2]), len(m[1]))
+	case bulletItem.MatchString(line):
+		r.item("ul", bulletItem.FindStringSubmatch(line)[1])
+	case orderedItem.MatchString(line):
+		r.item("ol", orderedItem.FindStringSubmatch(line)[1])
+	case quote.MatchString(line):
+		r.flush()
+		fmt.Fprintf(&r.out, "<blockquote>%s</blockquote>\n", inline(quote.FindStringSubmatch(line)[1]))
+	default:
+		r.closeList()
+		r.paragraph = append(r.paragraph, inline(strings.TrimSpace(line)))
+	}
+}
+
+func (r *renderer) item(list, text string) {
+	r.flushParagraph()
+	if r.list != list {
+		r.closeList()
+		r.list = list
+		fmt.Fprintf(&r.out, "<%s>\n", list)
+	}
+	fmt.Fprintf(&r.out, "<li>%s</li>\n", inline(text))
+}
+
+func (r *renderer) closeList() {
+	if r.list != "" {
+		fmt.Fprintf(&r.out, "</%s>\n", r.list)
+		r.list = ""
+	}
+}
+
+func (r *renderer) flushParagraph() {
+	if len(r.paragraph) > 0 {
+		fmt.Fprintf(&r.out, "<p>%s</p>\n", strings.Join(r.paragraph, "<br>\n"))
+		r.paragraph = nil
+	}
+}
+
+func (r *renderer) flush() {
+	r.flushParagraph()
+	r.closeList()
+}
+
+// inline escapes text and applies code spans, links and emphasis. code spans
+// are swapped out first so their content isn't formatted.
+func inline(text string) string {
+	var codes []string
+	text = codeSpan.ReplaceAllStringFunc(text, func(m string) string {
+		codes = append(codes, "<code>"+html.EscapeString(m[1:len(m)-1])+"</code>")
+		return fmt.Sprintf("\x00%d\x00", len(codes)-1)
+	})
+	text = html.EscapeString(text)
+	text = link.ReplaceAllStringFunc(text, func(m string) string {
+		parts := link.FindStringSubmatch(m)
+		label, href := parts[1], html.UnescapeString(parts[2])
+		if !safeURL(href) {
+			return label
+		}
+		return fmt.Sprintf(`<a href="%s" rel="nofollow noopener noreferrer">%s</a>`, html.EscapeString(href), label)
+	})
+	text = bold.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = italic.ReplaceAllString(text, "<em>$1$2</em>")
+	for i, code := range codes {
+		text = strings.Replace(text, fmt.Sprintf("\x00%d\x00", i), code, 1)
+	}
+	return text
+}
+
+func safeURL(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+	return allowedSchemes[strings.ToLower(u.Scheme)]
+}
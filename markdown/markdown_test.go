@@ -0,0 +1,46 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	cases := []struct {
+		name, src, want string
+	}{
+		{"paragraphs", "hello\nworld\n\nbye", "<p>hello<br>\nworld</p>\n<p>bye</p>\n"},
+		{"emphasis", "**bold** and *italic* and _also_", "<p><strong>bold</strong> and <em>italic</em> and <em>also</em></p>\n"},
+		{"heading", "## Notes", "<h2>Notes</h2>\n"},
+		{"bullet list", "- one\n- two\n\nafter", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n<p>after</p>\n"},
+		{"ordered list", "1. one\n2. two", "<ol>\n<li>one</li>\n<li>two</li>\n</ol>\n"},
+		{"code span isn't formatted", "`**x**`", "<p><code>**x**</code></p>\n"},
+		{"fenced code", "```\n<b>x</b>\n```", "<pre><code>&lt;b&gt;x&lt;/b&gt;\n</code></pre>\n"},
+		{"quote", "> said", "<blockquote>said</blockquote>\n"},
+		{"link", "[site](https://example.com/?a=1&b=2)", `<p><a href="https://example.com/?a=1&amp;b=2" rel="nofollow noopener noreferrer">site</a></p>` + "\n"},
+	}
+	for _, c := range cases {
+		if got := Render(c.src); got != c.want {
+			t.Errorf("%s: got %q, wanted %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestRenderSanitizes(t *testing.T) {
+	cases := map[string]string{
+		"script tag":         "<script>alert(1)</script>",
+		"javascript link":    "[click](javascript:alert(1))",
+		"data link":          "[click](data:text/html,<script>alert(1)</script>)",
+		"attribute breakout": `[x](https://a.com/"onmouseover="alert(1))`,
+		"html in heading":    "# <img src=x onerror=alert(1)>",
+		"html in code span":  "`<script>`",
+	}
+	for name, src := range cases {
+		got := Render(src)
+		for _, bad := range []string{"<script", "<img", "javascript:", "data:", `"onmouseover`} {
+			if strings.Contains(got, bad) {
+				t.Errorf("%s: output %q contains %q", name, got, bad)
+			}
+		}
+	}
+}
@@ -1,9 +1,150 @@
 package models
 
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rezbow/contact-app/phone"
+)
+
+var (
+	PhoneLabels   = []string{"mobile", "home", "work", "other"}
+	EmailLabels   = []string{"home", "work", "other"}
+	AddressLabels = []string{"home", "work", "other"}
+)
+
+type Phone struct {
+	Label string
+	// as it was entered
+	Number string
+	// the number in E.164 form, e.g. +14155550123, see package phone. empty
+	// for numbers entered before numbers were checked
+	E164 string
+}
+
+// Display formats the number nationally when it is a number of region and
+// internationally otherwise, or shows it as entered when it never parsed
+func (p Phone) Display(region string) string {
+	if n, err := phone.Parse(p.E164, region); p.E164 != "" && err == nil {
+		return n.Format(region)
+	}
+	return p.Number
+}
+
+type Email struct {
+	Label   string
+	Address string
+}
+
+type Address struct {
+	Label      string
+	Street     string
+	City       string
+	Region     string
+	PostalCode string
+	Country    string
+}
+
+// one line form, skipping empty parts
+func (a Address) String() string {
+	var parts []string
+	for _, part := range []string{a.Street, a.City, a.Region, a.PostalCode, a.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (a Address) Empty() bool {
+	return a.String() == ""
+}
+
 type Contact struct {
-	ID          int
-	FirstName   string
-	LastName    string
-	PhoneNumber string
-	Email       string
+	ID        int
+	FirstName string
+	LastName  string
+	Phones    []Phone
+	Emails    []Email
+	Addresses []Address
+	Company   string
+	JobTitle  string
+	// date only, zero when unknown
+	Birthday time.Time
+	Website  string
+	// markdown
+	Notes string
+	// values of custom fields by key, see FieldDefinition
+	Custom map[string]string
+	// normalized and sorted, see NormalizeTags
+	Tags []string
+	// id of the photo, see package photo. empty when there is none
+	Photo string
+	// set by the store when the contact is added, zero for contacts that
+	// predate it
+	CreatedAt time.Time
+	// set by the store whenever the contact is added or edited
+	UpdatedAt time.Time
+	// set by the store to 1 when the contact is added and bumped by every
+	// edit, so that edits made to an older version can be told apart. zero
+	// for contacts that predate it
+	Version int
+	// time of the latest interaction, kept up to date by the store rather
+	// than edited. zero when never contacted
+	LastContacted time.Time
+	// set while the contact is in the trash
+	DeletedAt time.Time
+}
+
+func (c Contact) Deleted() bool {
+	return !c.DeletedAt.IsZero()
+}
+
+// the first listed phone number, shown where there's room for only one
+func (c Contact) PrimaryPhone() string {
+	if len(c.Phones) == 0 {
+		return ""
+	}
+	return c.Phones[0].Number
+}
+
+// the first listed email, shown where there's room for only one
+func (c Contact) PrimaryEmail() string {
+	if len(c.Emails) == 0 {
+		return ""
+	}
+	return c.Emails[0].Address
+}
+
+// emails are compared case-insensitively
+func (c Contact) HasEmail(address string) bool {
+	for _, email := range c.Emails {
+		if strings.EqualFold(email.Address, address) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Contact) HasTag(tag string) bool {
+	return slices.Contains(c.Tags, tag)
+}
+
+func (c Contact) Contacted() bool {
+	return !c.LastContacted.IsZero()
+}
+
+func (c Contact) HasBirthday() bool {
+	return !c.Birthday.IsZero()
+}
+
+// NextBirthday is the first birthday on or after the date day. people born
+// on February 29 celebrate on March 1 in other years.
+func (c Contact) NextBirthday(day time.Time) time.Time {
+	next := time.Date(day.Year(), c.Birthday.Month(), c.Birthday.Day(), 0, 0, 0, 0, time.UTC)
+	if next.Before(day) {
+		next = time.Date(day.Year()+1, c.Birthday.Month(), c.Birthday.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return next
 }
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type FieldType string
+
+const (
+	FieldText    FieldType = "text"
+	FieldNumber  FieldType = "number"
+	FieldDate    FieldType = "date"
+	FieldEnum    FieldType = "enum"
+	FieldURL     FieldType = "url"
+	FieldBoolean FieldType = "boolean"
+)
+
+var FieldTypes = []FieldType{FieldText, FieldNumber, FieldDate, FieldEnum, FieldURL, FieldBoolean}
+
+var (
+	ErrRequired       = errors.New("must not be empty")
+	ErrInvalidFieldID = errors.New("key must start with a letter and contain only a-z, 0-9 and _")
+)
+
+var validFieldKey = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// FieldDefinition describes a user-defined contact field. values are kept on
+// the contact as canonical strings, see Normalize.
+type FieldDefinition struct {
+	Key      string
+	Label    string
+	Type     FieldType
+	Required bool
+	// allowed values of enum fields
+	Options []string
+	// bounds of number fields
+	Min, Max *float64
+	// text fields only, the whole value has to match
+	Pattern   string
+	MaxLength int
+}
+
+// Check validates the definition itself
+func (d FieldDefinition) Check() error {
+	if !validFieldKey.MatchString(d.Key) {
+		return ErrInvalidFieldID
+	}
+	if strings.TrimSpace(d.Label) == "" {
+		return errors.New("label must not be empty")
+	}
+	known := false
+	for _, t := range FieldTypes {
+		known = known || t == d.Type
+	}
+	if !known {
+		return fmt.Errorf("unknown field type %q", d.Type)
+	}
+	if d.Type == FieldEnum && len(d.Options) == 0 {
+		return errors.New("enum fields need at least one option")
+	}
+	if d.Pattern != "" {
+		if _, err := regexp.Compile(d.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	if d.Min != nil && d.Max != nil && *d.Min > *d.Max {
+		return errors.New("min must not be greater than max")
+	}
+	return nil
+}
+
+// Normalize validates value and returns it in canonical form, "" meaning unset
+func (d FieldDefinition) Normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if d.Type == FieldBoolean {
+		switch strings.ToLower(value) {
+		case "true", "on", "yes", "1":
+			value = "true"
+		default:
+			value = ""
+		}
+	}
+	if value == "" {
+		if d.Required {
+			return "", ErrRequired
+		}
+		return "", nil
+	}
+
+	switch d.Type {
+	case FieldText:
+		if d.MaxLength > 0 && len([]rune(value)) > d.MaxLength {
+			return "", fmt.Errorf("must be at most %d characters", d.MaxLength)
+		}
+		if d.Pattern != "" {
+			if re, err := regexp.Compile("^(?:" + d.Pattern + ")$"); err == nil && !re.MatchString(value) {
+				return "", fmt.Errorf("must match %s", d.Pattern)
+			}
+		}
+	case FieldNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", errors.New("must be a number")
+		}
+		if d.Min != nil && n < *d.Min {
+			return "", fmt.Errorf("must be at least %s", FormatNumber(*d.Min))
+		}
+		if d.Max != nil && n > *d.Max {
+			return "", fmt.Errorf("must be at most %s", FormatNumber(*d.Max))
+		}
+		value = FormatNumber(n)
+	case FieldDate:
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return "", errors.New("must be a date like 2026-12-31")
+		}
+	case FieldEnum:
+		for _, option := range d.Options {
+			if option == value {
+				return value, nil
+			}
+		}
+		return "", fmt.Errorf("must be one of %s", strings.Join(d.Options, ", "))
+	case FieldURL:
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", errors.New("must be a http or https address")
+		}
+	}
+	return value, nil
+}
+
+func FormatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// fields of a contact that show up in diffs, in display order. multi-valued
+// fields are flattened so any change to them is one field change.
+func contactFields(c Contact) [][2]string {
+	var phones, emails, addresses []string
+	for _, phone := range c.Phones {
+		phones = append(phones, phone.Label+": "+phone.Number)
+	}
+	for _, email := range c.Emails {
+		emails = append(emails, email.Label+": "+email.Address)
+	}
+	for _, address := range c.Addresses {
+		addresses = append(addresses, address.Label+": "+address.String())
+	}
+	var birthday string
+	if c.HasBirthday() {
+		birthday = c.Birthday.Format(time.DateOnly)
+	}
+	return [][2]string{
+		{"first_name", c.FirstName},
+		{"last_name", c.LastName},
+		{"phones", strings.Join(phones, "; ")},
+		{"emails", strings.Join(emails, "; ")},
+		{"addresses", strings.Join(addresses, "; ")},
+		{"company", c.Company},
+		{"job_title", c.JobTitle},
+		{"birthday", birthday},
+		{"website", c.Website},
+		{"notes", c.Notes},
+		{"tags", strings.Join(c.Tags, ", ")},
+		{"photo", c.Photo},
+	}
+}
+
+// DiffContacts lists the fields that differ between before and after. pass a
+// zero Contact as before for a creation and as after for a deletion.
+func DiffContacts(before, after Contact) []FieldChange {
+	var changes []FieldChange
+	a, b := contactFields(before), contactFields(after)
+	for i := range a {
+		if a[i][1] != b[i][1] {
+			changes = append(changes, FieldChange{Field: a[i][0], Before: a[i][1], After: b[i][1]})
+		}
+	}
+	// custom fields come last, by key, as "custom.<key>"
+	keys := slices.Collect(maps.Keys(before.Custom))
+	for key := range after.Custom {
+		if _, ok := before.Custom[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if before.Custom[key] != after.Custom[key] {
+			changes = append(changes, FieldChange{Field: "custom." + key, Before: before.Custom[key], After: after.Custom[key]})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// ContactFilter narrows down and orders the contact list, empty fields match
+// everything
+type ContactFilter struct {
+	// searched for in names and other text fields, see MatchesQuery
+	Query string
+	// a normalized tag the contact must have
+	Tag string
+	// when set, only contacts not contacted since, including the ones never
+	// contacted
+	NotContactedSince time.Time
+	// anything else a contact must match, e.g. a parsed search query
+	Condition Condition
+	// the order of the results, see SortContacts
+	Sort Sort
+}
+
+// Condition narrows down contacts beyond the fields of ContactFilter. it is
+// compared by Empty, so implementations should be pointers.
+type Condition interface {
+	Matches(Contact) bool
+}
+
+func (f ContactFilter) Empty() bool {
+	return f == ContactFilter{}
+}
+
+// Matches reports whether c passes the filter, see MatchesQuery for how the
+// query is searched
+func (f ContactFilter) Matches(c Contact) bool {
+	if f.Tag != "" && !c.HasTag(f.Tag) {
+		return false
+	}
+	if !f.NotContactedSince.IsZero() && !c.LastContacted.Before(f.NotContactedSince) {
+		return false
+	}
+	if f.Condition != nil && !f.Condition.Matches(c) {
+		return false
+	}
+	return MatchesQuery(c, f.Query)
+}
@@ -0,0 +1,53 @@
+package models
+
+import "slices"
+
+// Group is a named, ordered list of contacts, e.g. a mailing list
+type Group struct {
+	ID          int
+	Name        string
+	Description string
+	// contact ids in list order
+	Members []int
+}
+
+func (g Group) HasMember(contactID int) bool {
+	return slices.Contains(g.Members, contactID)
+}
+
+// AddMembers appends the contacts that aren't members yet, keeping their order
+func (g *Group) AddMembers(contactIDs ...int) {
+	for _, id := range contactIDs {
+		if !g.HasMember(id) {
+			g.Members = append(g.Members, id)
+		}
+	}
+}
+
+func (g *Group) RemoveMember(contactID int) {
+	g.Members = slices.DeleteFunc(g.Members, func(id int) bool { return id == contactID })
+}
+
+// ReplaceMember puts contactID in the place of from, or drops from when
+// contactID is a member already
+func (g *Group) ReplaceMember(from, contactID int) {
+	i := slices.Index(g.Members, from)
+	if i < 0 {
+		return
+	}
+	if g.HasMember(contactID) {
+		g.RemoveMember(from)
+		return
+	}
+	g.Members[i] = contactID
+}
+
+// MoveMember moves a member by offset places, stopping at either end
+func (g *Group) MoveMember(contactID, offset int) {
+	from := slices.Index(g.Members, contactID)
+	if from < 0 {
+		return
+	}
+	to := min(max(from+offset, 0), len(g.Members)-1)
+	g.Members = slices.Insert(slices.Delete(g.Members, from, from+1), to, contactID)
+}
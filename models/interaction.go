@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+type InteractionType struct {
+	Key   string
+	Label string
+}
+
+var InteractionTypes = []InteractionType{
+	{Key: "call", Label: "Call"},
+	{Key: "meeting", Label: "Meeting"},
+	{Key: "email", Label: "Email"},
+	{Key: "message", Label: "Message"},
+	{Key: "note", Label: "Note"},
+}
+
+func LookupInteractionType(key string) (InteractionType, bool) {
+	for _, t := range InteractionTypes {
+		if t.Key == key {
+			return t, true
+		}
+	}
+	return InteractionType{}, false
+}
+
+// Interaction is something that happened with a contact, e.g. a call, and
+// makes up their timeline
+type Interaction struct {
+	ID        int
+	ContactID int
+	Type      string
+	Time      time.Time
+	Summary   string
+	// date only, zero when nothing needs to follow
+	FollowUp time.Time
+	Author   string
+}
+
+func (i Interaction) HasFollowUp() bool {
+	return !i.FollowUp.IsZero()
+}
+
+// LastContacted is the time of the latest interaction, zero when there is none
+func LastContacted(interactions []Interaction) time.Time {
+	var last time.Time
+	for _, i := range interactions {
+		if i.Time.After(last) {
+			last = i.Time
+		}
+	}
+	return last
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// fields of DiffContacts that a merge takes from one contact or the other,
+// along with custom fields. the others are combined.
+var mergedFields = []string{"first_name", "last_name", "company", "job_title", "birthday", "website", "notes", "photo"}
+
+// MergeChoice is a field two contacts being merged disagree on
+type MergeChoice struct {
+	Field string
+	A, B  string
+}
+
+// MergeChoices lists the fields where a and b differ and one has to win, in
+// the order of DiffContacts
+func MergeChoices(a, b Contact) []MergeChoice {
+	var choices []MergeChoice
+	for _, change := range DiffContacts(a, b) {
+		if slices.Contains(mergedFields, change.Field) || strings.HasPrefix(change.Field, "custom.") {
+			choices = append(choices, MergeChoice{Field: change.Field, A: change.Before, B: change.After})
+		}
+	}
+	return choices
+}
+
+// MergeContacts merges other into keep. the fields of MergeChoices named in
+// fromOther take the value of other, as do fields keep has no value for.
+// phones, emails, addresses and tags are those of both without duplicates.
+// the result is keep, with its ID and Version, added when the older of the
+// two was.
+func MergeContacts(keep, other Contact, fromOther []string) Contact {
+	merged := keep
+	merged.Custom = maps.Clone(keep.Custom)
+	for _, choice := range MergeChoices(keep, other) {
+		if choice.A == "" || slices.Contains(fromOther, choice.Field) {
+			takeField(&merged, other, choice.Field)
+		}
+	}
+	merged.Phones = slices.Clone(keep.Phones)
+	for _, phone := range other.Phones {
+		if !slices.ContainsFunc(merged.Phones, func(p Phone) bool { return SamePhone(p, phone) }) {
+			merged.Phones = append(merged.Phones, phone)
+		}
+	}
+	merged.Emails = slices.Clone(keep.Emails)
+	for _, email := range other.Emails {
+		if !merged.HasEmail(email.Address) {
+			merged.Emails = append(merged.Emails, email)
+		}
+	}
+	merged.Addresses = slices.Clone(keep.Addresses)
+	for _, address := range other.Addresses {
+		if !slices.ContainsFunc(merged.Addresses, func(a Address) bool { return Fold(a.String()) == Fold(address.String()) }) {
+			merged.Addresses = append(merged.Addresses, address)
+		}
+	}
+	merged.Tags = NormalizeTags(append(slices.Clone(keep.Tags), other.Tags...))
+	if !other.CreatedAt.IsZero() && (keep.CreatedAt.IsZero() || other.CreatedAt.Before(keep.CreatedAt)) {
+		merged.CreatedAt = other.CreatedAt
+	}
+	if other.LastContacted.After(keep.LastContacted) {
+		merged.LastContacted = other.LastContacted
+	}
+	return merged
+}
+
+// takeField sets a field of c, named as in DiffContacts, to its value in from
+func takeField(c *Contact, from Contact, field string) {
+	switch field {
+	case "first_name":
+		c.FirstName = from.FirstName
+	case "last_name":
+		c.LastName = from.LastName
+	case "company":
+		c.Company = from.Company
+	case "job_title":
+		c.JobTitle = from.JobTitle
+	case "birthday":
+		c.Birthday = from.Birthday
+	case "website":
+		c.Website = from.Website
+	case "notes":
+		c.Notes = from.Notes
+	case "photo":
+		c.Photo = from.Photo
+	default:
+		key, ok := strings.CutPrefix(field, "custom.")
+		if !ok {
+			return
+		}
+		if value, ok := from.Custom[key]; ok {
+			if c.Custom == nil {
+				c.Custom = make(map[string]string)
+			}
+			c.Custom[key] = value
+		} else {
+			delete(c.Custom, key)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page is a slice of the contact list: the Number-th page of Size contacts,
+// or the Size contacts after a cursor
+type Page struct {
+	// from 1, ignored when After is set
+	Number int
+	Size   int
+	// the last contact of the previous page, see ContactPage.Next
+	After *Cursor
+}
+
+// ContactPage is a page of contacts and where the list goes on from it
+type ContactPage struct {
+	Contacts []Contact
+	// pages of the list by number
+	TotalPages int
+	// the cursor of the next page, nil on the last one
+	Next *Cursor
+}
+
+// Cursor points at a contact for the page after it to start from. it holds
+// the contact's sort key rather than its position, so rows added or deleted
+// before it don't shift the pages that follow.
+type Cursor struct {
+	Sort Sort `json:"s"`
+	// see Sort.KeyOf
+	Key string `json:"k"`
+	ID  int    `json:"i"`
+	// how many contacts came before and including the contact. search
+	// results ranked by relevance have no key to resume from, they resume
+	// after the contact, or at its offset when it has gone
+	Offset int `json:"o"`
+}
+
+// String is the cursor as an opaque token for URLs
+func (c Cursor) String() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseCursor reads the token of Cursor.String
+func ParseCursor(token string) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 || c.Offset < 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// PageOf cuts page out of contacts, which are in the order of sort, or
+// ranked by relevance when ranked is set
+func PageOf(contacts []Contact, sort Sort, ranked bool, page Page) ContactPage {
+	size := max(page.Size, 1)
+	result := ContactPage{TotalPages: (len(contacts) + size - 1) / size}
+	start := (max(page.Number, 1) - 1) * size
+	if after := page.After; after != nil {
+		if ranked {
+			start = after.Offset
+			if i := slices.IndexFunc(contacts, func(c Contact) bool { return c.ID == after.ID }); i >= 0 {
+				start = i + 1
+			}
+		} else {
+			start, _ = slices.BinarySearchFunc(contacts, after, func(c Contact, after *Cursor) int {
+				if sort.compareKeys(sort.KeyOf(c), c.ID, after.Key, after.ID) <= 0 {
+					return -1
+				}
+				return 1
+			})
+		}
+	}
+	start = min(start, len(contacts))
+	end := min(start+size, len(contacts))
+	result.Contacts = slices.Clone(contacts[start:end])
+	if end < len(contacts) && end > start {
+		last := contacts[end-1]
+		result.Next = &Cursor{Sort: sort, Key: sort.KeyOf(last), ID: last.ID, Offset: end}
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+package models
+
+// RelationType names how a related contact stands to a contact, e.g. the
+// related contact is the contact's "manager"
+type RelationType struct {
+	Key   string
+	Label string
+	// the type seen from the related contact
+	Inverse string
+}
+
+var RelationTypes = []RelationType{
+	{Key: "manager", Label: "Manager", Inverse: "report"},
+	{Key: "report", Label: "Reports to them", Inverse: "manager"},
+	{Key: "assistant", Label: "Assistant", Inverse: "executive"},
+	{Key: "executive", Label: "Assists them", Inverse: "assistant"},
+	{Key: "spouse", Label: "Spouse", Inverse: "spouse"},
+	{Key: "colleague", Label: "Colleague", Inverse: "colleague"},
+	{Key: "friend", Label: "Friend", Inverse: "friend"},
+}
+
+func LookupRelationType(key string) (RelationType, bool) {
+	for _, t := range RelationTypes {
+		if t.Key == key {
+			return t, true
+		}
+	}
+	return RelationType{}, false
+}
+
+// Relationship says RelatedID is the Type of ContactID, e.g. their manager.
+// a mutual relationship also shows up on the related contact, with the
+// inverse type.
+type Relationship struct {
+	ID        int
+	ContactID int
+	RelatedID int
+	Type      string
+	Mutual    bool
+}
+
+// Involves reports whether contactID is on either side
+func (r Relationship) Involves(contactID int) bool {
+	return r.ContactID == contactID || r.RelatedID == contactID
+}
+
+// From turns the relationship around as seen from contactID, returning the
+// other contact and the type they are to contactID
+func (r Relationship) From(contactID int) (otherID int, relationType string) {
+	if r.ContactID == contactID {
+		return r.RelatedID, r.Type
+	}
+	t, _ := LookupRelationType(r.Type)
+	return r.ContactID, t.Inverse
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Reminder is something to do about a contact by a date, e.g. follow up on a
+// call
+type Reminder struct {
+	ID        int
+	ContactID int
+	// date only
+	Due  time.Time
+	Note string
+	Done bool
+	// set by the reminder ticker once the reminder is due, which puts it in
+	// the notification badge until it is done
+	Notified bool
+}
+
+// Overdue reports whether the reminder is still open after its due date
+func (r Reminder) Overdue(today time.Time) bool {
+	return !r.Done && r.Due.Before(today)
+}
+
+// DueBy reports whether the reminder is open and due on or before day
+func (r Reminder) DueBy(day time.Time) bool {
+	return !r.Done && !r.Due.After(day)
+}
+
+// DateOf is the date of t as a date only time, the way dates like birthdays
+// and due dates are stored
+func DateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
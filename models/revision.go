@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Revision is a snapshot of a contact after a change
+type Revision struct {
+	ContactID int
+	// starts at 1 for every contact
+	Number  int
+	Contact Contact
+	Time    time.Time
+	Author  string
+	// number of the revision this one restored, zero for normal edits
+	RevertedFrom int
+	// id of the contact this revision was taken over from when it was merged
+	// into this one, zero for revisions of the contact itself
+	MergedFrom int
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/rezbow/contact-app/phone"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fold brings text to the form searches compare: case folded, with accents
+// and other combining marks dropped, so "chloé" and "CHLOE" are the same
+func Fold(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	return cases.Fold().String(folded)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Tokenize splits text into folded words, e.g. "chloe@atelier.fr" into
+// "chloe", "atelier" and "fr"
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(Fold(s), func(r rune) bool { return !isWordRune(r) })
+}
+
+// digitsOf keeps only the digits of s, the part of a phone number that
+// matters when searching
+func digitsOf(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// PhoneDigits are the digits of a phone number in the forms it is searched
+// by: as entered, and E.164 and national when it parsed
+func PhoneDigits(p Phone) []string {
+	forms := []string{digitsOf(p.Number)}
+	if n, err := phone.Parse(p.E164, ""); p.E164 != "" && err == nil {
+		forms = append(forms, digitsOf(n.E164()), digitsOf(n.FormatNational()))
+	}
+	return forms
+}
+
+// phone numbers sharing this many trailing digits are the same number, as
+// one may have a country or area code the other leaves out
+const phoneTailDigits = 7
+
+// SamePhone reports whether a and b are the same number: equal in E.164 when
+// both parsed, otherwise when a form of one of PhoneDigits is a form of the
+// other or ends in its last phoneTailDigits or more digits
+func SamePhone(a, b Phone) bool {
+	if a.E164 != "" && b.E164 != "" {
+		return a.E164 == b.E164
+	}
+	for _, x := range PhoneDigits(a) {
+		for _, y := range PhoneDigits(b) {
+			if len(x) < len(y) {
+				x, y = y, x
+			}
+			if y != "" && (x == y || len(y) >= phoneTailDigits && strings.HasSuffix(x, y)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// a query like "+1 (555) 123-45" is a phone number, compared by its digits
+func isPhoneQuery(q string) bool {
+	for _, r := range q {
+		if !strings.ContainsRune("0123456789+-(). ", r) {
+			return false
+		}
+	}
+	return len(digitsOf(q)) >= 3
+}
+
+// QueryTerms are what a search looks for. a query that looks like a phone
+// number is a single term of its digits.
+func QueryTerms(query string) []string {
+	if isPhoneQuery(query) {
+		return []string{digitsOf(query)}
+	}
+	return Tokenize(query)
+}
+
+// SearchField is a searchable part of a contact
+type SearchField struct {
+	Name   string
+	Tokens []string
+}
+
+// names of the fields of ContactSearchFields
+const (
+	SearchName    = "name"
+	SearchEmail   = "email"
+	SearchPhone   = "phone"
+	SearchCompany = "company"
+	SearchNotes   = "notes"
+	SearchCustom  = "custom"
+)
+
+// ContactSearchFields are the tokens of a contact by field. phone numbers
+// are tokens of every tail of their digits, so any run of digits in a
+// number matches it as a prefix, and of their E.164 and national forms, so
+// they match however they are written.
+func ContactSearchFields(c Contact) []SearchField {
+	var emails, phones, custom []string
+	for _, email := range c.Emails {
+		emails = append(emails, Tokenize(email.Address)...)
+	}
+	for _, p := range c.Phones {
+		for _, digits := range PhoneDigits(p) {
+			for i := range digits {
+				if !slices.Contains(phones, digits[i:]) {
+					phones = append(phones, digits[i:])
+				}
+			}
+		}
+	}
+	for _, value := range c.Custom {
+		custom = append(custom, Tokenize(value)...)
+	}
+	return []SearchField{
+		{Name: SearchName, Tokens: Tokenize(c.FirstName + " " + c.LastName)},
+		{Name: SearchEmail, Tokens: emails},
+		{Name: SearchPhone, Tokens: phones},
+		{Name: SearchCompany, Tokens: Tokenize(c.Company + " " + c.JobTitle)},
+		{Name: SearchNotes, Tokens: Tokenize(c.Notes)},
+		{Name: SearchCustom, Tokens: custom},
+	}
+}
+
+// MatchesQuery is how every store searches contacts: each term of the query
+// has to start some word of the contact's names, emails, phone numbers,
+// company, job title, notes or custom values. case and accents don't
+// matter, and phone numbers match by their digits whatever the formatting.
+// an empty query matches everything.
+func MatchesQuery(c Contact, query string) bool {
+	fields := ContactSearchFields(c)
+	for _, term := range QueryTerms(query) {
+		if !hasPrefixToken(fields, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasPrefixToken(fields []SearchField, term string) bool {
+	for _, field := range fields {
+		for _, token := range field.Tokens {
+			if strings.HasPrefix(token, term) {
+				return true
+			}
+		}
+	}
+	return false
+}
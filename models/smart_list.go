@@ -0,0 +1,42 @@
+package models
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// SmartList is a saved search of the contact list. its contacts are whatever
+// matches the search at the time, so they change with the address book.
+type SmartList struct {
+	ID   int
+	Name string
+	// the search box, see package query
+	Query string
+	// a normalized tag
+	Tag string
+	// days nobody got in touch with the contacts, 0 for any time
+	Untouched int
+}
+
+// Values are the query parameters of the contact list showing the list
+func (l SmartList) Values() url.Values {
+	values := url.Values{}
+	if l.Query != "" {
+		values.Set("q", l.Query)
+	}
+	if l.Tag != "" {
+		values.Set("tag", l.Tag)
+	}
+	if l.Untouched > 0 {
+		values.Set("untouched", strconv.Itoa(l.Untouched))
+	}
+	if l.ID != 0 {
+		values.Set("list", strconv.Itoa(l.ID))
+	}
+	return values
+}
+
+// URL is the contact list showing the list
+func (l SmartList) URL() string {
+	return "/contacts?" + l.Values().Encode()
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// SortKey is what the contact list is ordered by
+type SortKey string
+
+const (
+	// the order contacts were added in, or relevance when searching.
+	// descending it is newest first.
+	SortDefault   SortKey = ""
+	SortFirstName SortKey = "first"
+	SortLastName  SortKey = "last"
+	SortEmail     SortKey = "email"
+	SortCreated   SortKey = "created"
+	SortUpdated   SortKey = "updated"
+)
+
+var sortKeys = []SortKey{SortFirstName, SortLastName, SortEmail, SortCreated, SortUpdated}
+
+// Sort orders contacts by a key, breaking ties by ID in the same direction so
+// that the order is total
+type Sort struct {
+	Key  SortKey
+	Desc bool
+}
+
+// ParseSort reads the sort and order query parameters of the contact list,
+// unknown keys sort by default
+func ParseSort(key, order string) Sort {
+	sort := Sort{Desc: order == "desc"}
+	if slices.Contains(sortKeys, SortKey(key)) {
+		sort.Key = SortKey(key)
+	}
+	return sort
+}
+
+// Order is the order query parameter
+func (s Sort) Order() string {
+	if s.Desc {
+		return "desc"
+	}
+	return "asc"
+}
+
+// keyTime formats times so that they sort as strings
+const keyTime = "2006-01-02T15:04:05.000000000Z"
+
+// KeyOf is what c is sorted by as a string. names and emails are compared
+// the way search matches them, ignoring case and accents.
+func (s Sort) KeyOf(c Contact) string {
+	switch s.Key {
+	case SortFirstName:
+		return Fold(c.FirstName)
+	case SortLastName:
+		return Fold(c.LastName)
+	case SortEmail:
+		return Fold(c.PrimaryEmail())
+	case SortCreated:
+		return c.CreatedAt.UTC().Format(keyTime)
+	case SortUpdated:
+		return c.UpdatedAt.UTC().Format(keyTime)
+	}
+	return ""
+}
+
+// Compare orders a before b
+func (s Sort) Compare(a, b Contact) int {
+	return s.compareKeys(s.KeyOf(a), a.ID, s.KeyOf(b), b.ID)
+}
+
+func (s Sort) compareKeys(a string, aID int, b string, bID int) int {
+	c := strings.Compare(a, b)
+	if c == 0 {
+		c = cmp.Compare(aID, bID)
+	}
+	if s.Desc {
+		return -c
+	}
+	return c
+}
+
+// SortContacts sorts contacts in place. the default sort leaves them as they
+// are, in insertion or relevance order.
+func SortContacts(contacts []Contact, sort Sort) {
+	if sort == (Sort{}) {
+		return
+	}
+	slices.SortFunc(contacts, sort.Compare)
+}
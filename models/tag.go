@@ -0,0 +1,49 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+const MaxTagLength = 32
+
+// NormalizeTag lower cases a tag and collapses its whitespace, so "Family "
+// and "family" are the same tag
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// NormalizeTags returns the distinct normalized tags, sorted, dropping
+// blank ones
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		if tag = NormalizeTag(tag); tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
+}
+
+type TagCount struct {
+	Name  string
+	Count int
+}
+
+// CountTags lists every tag used by contacts with how many contacts have it,
+// by name
+func CountTags(contacts []Contact) []TagCount {
+	counts := make(map[string]int)
+	for _, c := range contacts {
+		for _, tag := range c.Tags {
+			counts[tag]++
+		}
+	}
+	tags := make([]TagCount, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, TagCount{Name: name, Count: count})
+	}
+	slices.SortFunc(tags, func(a, b TagCount) int { return strings.Compare(a.Name, b.Name) })
+	return tags
+}
@@ -0,0 +1,13 @@
+package models
+
+type User struct {
+	ID    int
+	Email string
+	Name  string
+	// empty for users that only sign in through sso
+	PasswordHash string
+	OIDCIssuer   string
+	OIDCSubject  string
+	// secret of the user's calendar feed url, empty until they ask for one
+	CalendarToken string
+}
@@ -0,0 +1,228 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrMalformedToken   = errors.New("malformed id token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrUnknownKey       = errors.New("id token signed with unknown key")
+	ErrInvalidSignature = errors.New("invalid id token signature")
+	ErrInvalidClaims    = errors.New("invalid id token claims")
+)
+
+// allowed difference between our clock and the provider's
+const clockSkew = time.Minute
+
+type IDToken struct {
+	Issuer        string
+	Subject       string
+	Audience      []string
+	Expiry        time.Time
+	IssuedAt      time.Time
+	Nonce         string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type claims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      audience `json:"aud"`
+	Expiry        int64    `json:"exp"`
+	IssuedAt      int64    `json:"iat"`
+	Nonce         string   `json:"nonce"`
+	AZP           string   `json:"azp"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	Name          string   `json:"name"`
+}
+
+// aud can be a single string or an array
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Verify checks the signature and claims of a raw id token. only RS256 is
+// supported, which every certified provider must offer.
+func (p *Provider) Verify(ctx context.Context, raw, nonce string) (*IDToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedToken, err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlg, header.Alg)
+	}
+	key, err := p.keys.get(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedToken, err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedToken, err)
+	}
+	if err := p.checkClaims(c, nonce); err != nil {
+		return nil, err
+	}
+	return &IDToken{
+		Issuer:        c.Issuer,
+		Subject:       c.Subject,
+		Audience:      c.Audience,
+		Expiry:        time.Unix(c.Expiry, 0),
+		IssuedAt:      time.Unix(c.IssuedAt, 0),
+		Nonce:         c.Nonce,
+		Email:         c.Email,
+		EmailVerified: c.EmailVerified,
+		Name:          c.Name,
+	}, nil
+}
+
+func (p *Provider) checkClaims(c claims, nonce string) error {
+	now := p.now()
+	switch {
+	case c.Issuer != p.metadata.Issuer:
+		return fmt.Errorf("%w: issuer %q", ErrInvalidClaims, c.Issuer)
+	case c.Subject == "":
+		return fmt.Errorf("%w: missing subject", ErrInvalidClaims)
+	case !contains(c.Audience, p.config.ClientID):
+		return fmt.Errorf("%w: audience %v", ErrInvalidClaims, c.Audience)
+	case len(c.Audience) > 1 && c.AZP != p.config.ClientID:
+		return fmt.Errorf("%w: authorized party %q", ErrInvalidClaims, c.AZP)
+	case now.After(time.Unix(c.Expiry, 0).Add(clockSkew)):
+		return fmt.Errorf("%w: token expired", ErrInvalidClaims)
+	case time.Unix(c.IssuedAt, 0).After(now.Add(clockSkew)):
+		return fmt.Errorf("%w: token issued in the future", ErrInvalidClaims)
+	case c.Nonce != nonce:
+		return fmt.Errorf("%w: nonce mismatch", ErrInvalidClaims)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// keySet caches the provider's signing keys and refetches them when a token
+// is signed with a key we haven't seen, which is how providers rotate keys.
+type keySet struct {
+	uri    string
+	client *http.Client
+	mu     sync.Mutex
+	keys   map[string]*rsa.PublicKey
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (k *keySet) get(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+	if err := k.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+}
+
+func (k *keySet) refresh(ctx context.Context) error {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := getJSON(ctx, k.client, k.uri, &set); err != nil {
+		return fmt.Errorf("fetching jwks: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		pub, err := key.rsa()
+		if err != nil {
+			continue
+		}
+		keys[key.Kid] = pub
+	}
+	k.keys = keys
+	return nil
+}
+
+func (j jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(j.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(j.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("rsa exponent too large")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
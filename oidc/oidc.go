@@ -0,0 +1,179 @@
+// Package oidc implements the relying party side of OpenID Connect:
+// discovery, the authorization code flow with PKCE and ID token verification.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrIssuerMismatch = errors.New("issuer in discovery document does not match")
+	ErrTokenExchange  = errors.New("code exchange failed")
+	ErrNoIDToken      = errors.New("token response has no id_token")
+)
+
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// defaults to openid, email and profile
+	Scopes []string
+}
+
+// Metadata is the subset of the discovery document we rely on
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type Provider struct {
+	config   Config
+	metadata Metadata
+	client   *http.Client
+	keys     *keySet
+	now      func() time.Time
+}
+
+// Discover fetches the provider's discovery document and returns a provider
+// ready to start logins.
+func Discover(ctx context.Context, config Config) (*Provider, error) {
+	return DiscoverWithClient(ctx, config, http.DefaultClient)
+}
+
+func DiscoverWithClient(ctx context.Context, config Config, client *http.Client) (*Provider, error) {
+	wellKnown := strings.TrimSuffix(config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	var metadata Metadata
+	if err := getJSON(ctx, client, wellKnown, &metadata); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimSuffix(metadata.Issuer, "/") != strings.TrimSuffix(config.IssuerURL, "/") {
+		return nil, fmt.Errorf("%w: got %q, wanted %q", ErrIssuerMismatch, metadata.Issuer, config.IssuerURL)
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{
+		config:   config,
+		metadata: metadata,
+		client:   client,
+		keys:     &keySet{uri: metadata.JWKSURI, client: client},
+		now:      time.Now,
+	}, nil
+}
+
+func (p *Provider) Metadata() Metadata {
+	return p.metadata
+}
+
+// AuthCodeURL is where the user is sent to log in. state and nonce must be
+// kept in the session and checked when the user comes back.
+func (p *Provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.config.ClientID)
+	q.Set("redirect_uri", p.config.RedirectURL)
+	q.Set("scope", strings.Join(p.config.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", CodeChallenge(codeVerifier))
+	q.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.metadata.AuthorizationEndpoint + sep + q.Encode()
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	Error       string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+// Exchange trades the authorization code for tokens and returns the raw id token
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	res, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrTokenExchange, err)
+	}
+	defer res.Body.Close()
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&token); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrTokenExchange, err)
+	}
+	if res.StatusCode != http.StatusOK || token.Error != "" {
+		return "", fmt.Errorf("%w: %s %s", ErrTokenExchange, token.Error, token.Description)
+	}
+	if token.IDToken == "" {
+		return "", ErrNoIDToken
+	}
+	return token.IDToken, nil
+}
+
+// NewCodeVerifier returns a random PKCE code verifier (RFC 7636)
+func NewCodeVerifier() string {
+	return randomString(32)
+}
+
+// NewState returns a random value usable as state or nonce
+func NewState() string {
+	return randomString(16)
+}
+
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func getJSON(ctx context.Context, client *http.Client, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %s", u, res.Status)
+	}
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(v)
+}
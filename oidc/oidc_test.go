@@ -0,0 +1,145 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/oidc"
+	"github.com/rezbow/contact-app/oidc/oidctest"
+)
+
+const redirectURL = "http://app.test/auth/oidc/callback"
+
+// follows the authorization redirect without leaving the provider and
+// returns the code it hands back to the app
+func authorize(t *testing.T, provider *oidc.Provider, state, nonce, verifier string) string {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(provider.AuthCodeURL(state, nonce, verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	location, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := location.Query().Get("state"); got != state {
+		t.Fatalf("got state %q back, wanted %q", got, state)
+	}
+	return location.Query().Get("code")
+}
+
+func TestProvider(t *testing.T) {
+	fake := oidctest.NewProvider()
+	defer fake.Close()
+	ctx := context.Background()
+
+	provider, err := oidc.Discover(ctx, fake.Config(redirectURL))
+	if err != nil {
+		t.Fatalf("discovery failed: %v", err)
+	}
+
+	t.Run("authorization code flow with pkce", func(t *testing.T) {
+		verifier := oidc.NewCodeVerifier()
+		code := authorize(t, provider, "state", "nonce", verifier)
+		raw, err := provider.Exchange(ctx, code, verifier)
+		if err != nil {
+			t.Fatalf("exchange failed: %v", err)
+		}
+		token, err := provider.Verify(ctx, raw, "nonce")
+		if err != nil {
+			t.Fatalf("verification failed: %v", err)
+		}
+		if token.Subject != fake.User.Subject || token.Email != fake.User.Email || !token.EmailVerified {
+			t.Errorf("got token %+v, wanted claims of %+v", token, fake.User)
+		}
+	})
+
+	t.Run("wrong code verifier is rejected", func(t *testing.T) {
+		code := authorize(t, provider, "state", "nonce", oidc.NewCodeVerifier())
+		_, err := provider.Exchange(ctx, code, oidc.NewCodeVerifier())
+		if !errors.Is(err, oidc.ErrTokenExchange) {
+			t.Errorf("got %v, wanted %v", err, oidc.ErrTokenExchange)
+		}
+	})
+
+	t.Run("code can only be used once", func(t *testing.T) {
+		verifier := oidc.NewCodeVerifier()
+		code := authorize(t, provider, "state", "nonce", verifier)
+		if _, err := provider.Exchange(ctx, code, verifier); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := provider.Exchange(ctx, code, verifier); !errors.Is(err, oidc.ErrTokenExchange) {
+			t.Errorf("got %v, wanted %v", err, oidc.ErrTokenExchange)
+		}
+	})
+
+	t.Run("nonce mismatch is rejected", func(t *testing.T) {
+		verifier := oidc.NewCodeVerifier()
+		code := authorize(t, provider, "state", "nonce", verifier)
+		raw, _ := provider.Exchange(ctx, code, verifier)
+		if _, err := provider.Verify(ctx, raw, "other"); !errors.Is(err, oidc.ErrInvalidClaims) {
+			t.Errorf("got %v, wanted %v", err, oidc.ErrInvalidClaims)
+		}
+	})
+
+	claimCases := map[string]map[string]any{
+		"expired token":      {"exp": time.Now().Add(-time.Hour).Unix()},
+		"wrong audience":     {"aud": "someone-else"},
+		"wrong issuer":       {"iss": "https://evil.example"},
+		"issued in future":   {"iat": time.Now().Add(time.Hour).Unix()},
+		"missing subject":    {"sub": ""},
+		"multi audience azp": {"aud": []string{fake.ClientID, "other"}},
+	}
+	for name, override := range claimCases {
+		t.Run(name+" is rejected", func(t *testing.T) {
+			claims := map[string]any{
+				"iss": fake.URL, "sub": "1", "aud": fake.ClientID, "nonce": "n",
+				"exp": time.Now().Add(time.Hour).Unix(), "iat": time.Now().Unix(),
+			}
+			for k, v := range override {
+				claims[k] = v
+			}
+			if _, err := provider.Verify(ctx, fake.Sign(claims), "n"); !errors.Is(err, oidc.ErrInvalidClaims) {
+				t.Errorf("got %v, wanted %v", err, oidc.ErrInvalidClaims)
+			}
+		})
+	}
+
+	t.Run("tampered payload fails signature check", func(t *testing.T) {
+		raw := fake.Sign(map[string]any{"iss": fake.URL, "sub": "1", "aud": fake.ClientID})
+		parts := strings.Split(raw, ".")
+		other := strings.Split(fake.Sign(map[string]any{"iss": fake.URL, "sub": "2", "aud": fake.ClientID}), ".")
+		forged := parts[0] + "." + other[1] + "." + parts[2]
+		if _, err := provider.Verify(ctx, forged, ""); !errors.Is(err, oidc.ErrInvalidSignature) {
+			t.Errorf("got %v, wanted %v", err, oidc.ErrInvalidSignature)
+		}
+	})
+
+	t.Run("unsigned token is rejected", func(t *testing.T) {
+		raw := "eyJhbGciOiJub25lIn0.eyJzdWIiOiIxIn0."
+		if _, err := provider.Verify(ctx, raw, ""); !errors.Is(err, oidc.ErrUnsupportedAlg) {
+			t.Errorf("got %v, wanted %v", err, oidc.ErrUnsupportedAlg)
+		}
+	})
+
+	t.Run("discovery rejects mismatched issuer", func(t *testing.T) {
+		config := fake.Config(redirectURL)
+		config.IssuerURL = fake.URL + "/"
+		if _, err := oidc.Discover(ctx, config); err != nil {
+			t.Errorf("trailing slash should be tolerated, got %v", err)
+		}
+		config.IssuerURL = fake.URL + "/tenant"
+		if _, err := oidc.Discover(ctx, config); err == nil {
+			t.Errorf("expected discovery error for wrong issuer")
+		}
+	})
+}
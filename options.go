@@ -0,0 +1,100 @@
+package contactapp
+
+import (
+	"time"
+
+	"github.com/rezbow/contact-app/oidc"
+)
+
+type Option func(*Server)
+
+func WithUserStore(users UserStore) Option {
+	return func(s *Server) {
+		s.users = users
+	}
+}
+
+// enables single sign-on through an OpenID Connect provider
+func WithOIDC(provider *oidc.Provider) Option {
+	return func(s *Server) {
+		s.oidc = provider
+	}
+}
+
+func WithAuditLog(log AuditLog) Option {
+	return func(s *Server) {
+		s.audit = log
+	}
+}
+
+func WithRevisionStore(revisions RevisionStore) Option {
+	return func(s *Server) {
+		s.revisions = revisions
+	}
+}
+
+// how long deleted contacts are kept in the trash before being purged
+func WithTrashRetention(retention time.Duration) Option {
+	return func(s *Server) {
+		s.trashRetention = retention
+	}
+}
+
+// how long a session lasts without being used
+func WithSessionIdleTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.sessions.idleTimeout = timeout
+	}
+}
+
+// the custom fields of the address book
+func WithFieldSchema(fields FieldSchema) Option {
+	return func(s *Server) {
+		s.fields = fields
+	}
+}
+
+func WithGroupStore(groups GroupStore) Option {
+	return func(s *Server) {
+		s.groups = groups
+	}
+}
+
+// where contact photos are kept
+func WithBlobStore(blobs BlobStore) Option {
+	return func(s *Server) {
+		s.blobs = blobs
+	}
+}
+
+func WithRelationshipStore(relations RelationshipStore) Option {
+	return func(s *Server) {
+		s.relations = relations
+	}
+}
+
+func WithInteractionStore(interactions InteractionStore) Option {
+	return func(s *Server) {
+		s.interactions = interactions
+	}
+}
+
+func WithReminderStore(reminders ReminderStore) Option {
+	return func(s *Server) {
+		s.reminders = reminders
+	}
+}
+
+func WithSmartListStore(lists SmartListStore) Option {
+	return func(s *Server) {
+		s.lists = lists
+	}
+}
+
+// the region of phone numbers entered without a country code, e.g. "GB",
+// see package phone
+func WithPhoneRegion(region string) Option {
+	return func(s *Server) {
+		s.phoneRegion = region
+	}
+}
@@ -3,6 +3,8 @@ package contactapp
 import (
 	"net/url"
 	"strconv"
+
+	"github.com/rezbow/contact-app/models"
 )
 
 const (
@@ -21,8 +23,20 @@ func extractPaginationData(r url.Values) (int, int) {
 		page = p
 	}
 
-	if l, err := strconv.Atoi(r.Get("limit")); err == nil && l < maxLimit {
+	if l, err := strconv.Atoi(r.Get("limit")); err == nil && l > 0 && l <= maxLimit {
 		limit = l
 	}
 	return page, limit
 }
+
+// extractPage is the page of the contact list the query asks for: the page
+// after ?cursor=, or the numbered page. a cursor of another sort than the
+// list's is ignored.
+func extractPage(r url.Values, sort models.Sort) models.Page {
+	number, size := extractPaginationData(r)
+	page := models.Page{Number: number, Size: size}
+	if cursor, err := models.ParseCursor(r.Get("cursor")); err == nil && cursor.Sort == sort {
+		page.After = &cursor
+	}
+	return page
+}
@@ -1,8 +1,14 @@
 package contactapp
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"regexp"
+	"strings"
 	"testing"
+
+	"github.com/rezbow/contact-app/models"
 )
 
 func TestExtractPaginationData(t *testing.T) {
@@ -28,6 +34,13 @@ func TestExtractPaginationData(t *testing.T) {
 		}
 	})
 
+	t.Run("limit must be positive", func(t *testing.T) {
+		someQuery, _ := url.ParseQuery("limit=0")
+		if _, limit := extractPaginationData(someQuery); limit != defaultLimit {
+			t.Errorf("expected limit be %d, got %d", defaultLimit, limit)
+		}
+	})
+
 	t.Run("limit greater than 100 must be default to 10", func(t *testing.T) {
 		someQuery, _ := url.ParseQuery("page=0&limit=1000")
 		_, limit := extractPaginationData(someQuery)
@@ -36,3 +49,49 @@ func TestExtractPaginationData(t *testing.T) {
 		}
 	})
 }
+
+func TestContactListPages(t *testing.T) {
+	store := NewinMemoryStore()
+	server := NewContactServer(store)
+	session := signedIn(server)
+	get := func(path string) string {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(newGetRequest(path), session))
+		assertCode(t, res.Code, http.StatusOK)
+		return res.Body.String()
+	}
+	loadMore := regexp.MustCompile(`hx-get="(/contacts\?[^"]*cursor=[^"]*)"`)
+
+	body := get("/contacts?limit=2&sort=last")
+	if !strings.Contains(body, "Page 1 of 2") || !strings.Contains(body, `href="/contacts?limit=2&amp;page=2&amp;sort=last"`) {
+		t.Errorf("expected a pager of 2 pages, got %s", body)
+	}
+	if strings.Contains(body, "Morgan") {
+		t.Errorf("expected 2 contacts per page")
+	}
+	match := loadMore.FindStringSubmatch(body)
+	if match == nil {
+		t.Fatalf("expected a Load More button, got %s", body)
+	}
+
+	// Doe was on the first page, deleting it doesn't skip Morgan
+	store.DeleteContact(2)
+	body = get(strings.ReplaceAll(match[1], "&amp;", "&"))
+	if !strings.Contains(body, "Morgan") || strings.Contains(body, "Jackson") || loadMore.MatchString(body) {
+		t.Errorf("expected only Morgan after the cursor, got %s", body)
+	}
+	store.RestoreContact(2)
+
+	t.Run("page numbers are kept for the pager", func(t *testing.T) {
+		if body := get("/contacts?limit=2&page=2"); !strings.Contains(body, "Morgan") || strings.Contains(body, "Doe") {
+			t.Errorf("expected Morgan alone on page 2, got %s", body)
+		}
+	})
+
+	t.Run("a cursor of another sort is ignored", func(t *testing.T) {
+		cursor := models.Cursor{Sort: models.Sort{Key: models.SortEmail}, Key: "zzz", ID: 3}
+		if body := get("/contacts?limit=2&cursor=" + cursor.String()); !strings.Contains(body, "Jackson") {
+			t.Errorf("expected the first page, got %s", body)
+		}
+	})
+}
@@ -0,0 +1,222 @@
+// Package phone parses phone numbers as people type them, in their national
+// form or with a country code, into E.164 and formats them back for display.
+// it knows the numbering plans of a handful of regions, not every rule of
+// every country.
+package phone
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInvalid        = errors.New("not a valid phone number")
+	ErrUnknownCountry = errors.New("unknown country code")
+)
+
+const (
+	minE164Digits = 7
+	maxE164Digits = 15
+)
+
+// plan is how the phone numbers of a region look
+type plan struct {
+	// ISO 3166 code, e.g. "US"
+	region string
+	// country calling code, e.g. "1"
+	code string
+	// dialled before a national number within the region, e.g. "0"
+	trunk string
+	// lengths of the national number, without the trunk prefix
+	lengths []int
+	// leading groups of digits of the national number when formatted, the
+	// rest is the last group
+	groups []int
+	// the north american numbering plan, formatted (415) 555-0123
+	nanp bool
+}
+
+// plans sharing a country code are listed by preference
+var plans = []plan{
+	{region: "US", code: "1", trunk: "1", lengths: []int{10}, groups: []int{3, 3}, nanp: true},
+	{region: "CA", code: "1", trunk: "1", lengths: []int{10}, groups: []int{3, 3}, nanp: true},
+	{region: "GB", code: "44", trunk: "0", lengths: []int{9, 10}, groups: []int{4}},
+	{region: "FR", code: "33", trunk: "0", lengths: []int{9}, groups: []int{1, 2, 2, 2}},
+	{region: "DE", code: "49", trunk: "0", lengths: []int{6, 7, 8, 9, 10, 11}, groups: []int{3}},
+	{region: "IR", code: "98", trunk: "0", lengths: []int{10}, groups: []int{3, 3}},
+	{region: "IN", code: "91", trunk: "0", lengths: []int{10}, groups: []int{5}},
+	{region: "AU", code: "61", trunk: "0", lengths: []int{9}, groups: []int{1, 4}},
+}
+
+func lookup(region string) (plan, bool) {
+	i := slices.IndexFunc(plans, func(p plan) bool { return p.region == strings.ToUpper(region) })
+	if i < 0 {
+		return plan{}, false
+	}
+	return plans[i], true
+}
+
+// ValidRegion reports whether numbers of region can be parsed without a
+// country code
+func ValidRegion(region string) bool {
+	_, ok := lookup(region)
+	return ok
+}
+
+// Number is a parsed phone number
+type Number struct {
+	// the region the number belongs to, e.g. "US". empty for countries
+	// without a plan, whose numbers aren't split into country code and
+	// national number
+	Region string
+	// country calling code, e.g. "1"
+	CountryCode string
+	// the national number without the trunk prefix, e.g. "4155550123", or
+	// every digit of a number of a country without a plan
+	National string
+}
+
+// Parse reads a number as typed, e.g. "(415) 555-0123" or
+// "+44 7400 123456". numbers without a country code, given by a leading +
+// or 00, are numbers of defaultRegion.
+func Parse(input, defaultRegion string) (Number, error) {
+	s := strings.TrimSpace(input)
+	international := strings.HasPrefix(s, "+")
+	s = strings.TrimPrefix(s, "+")
+	var digits strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case strings.ContainsRune(" -.()/", r):
+		default:
+			return Number{}, ErrInvalid
+		}
+	}
+	number := digits.String()
+	if !international && strings.HasPrefix(number, "00") {
+		international, number = true, number[2:]
+	}
+	if international {
+		return parseInternational(number, defaultRegion)
+	}
+	p, ok := lookup(defaultRegion)
+	if !ok {
+		return Number{}, ErrUnknownCountry
+	}
+	if !p.valid(number) && strings.HasPrefix(number, p.trunk) {
+		number = number[len(p.trunk):]
+	}
+	if !p.valid(number) {
+		return Number{}, ErrInvalid
+	}
+	return Number{Region: p.region, CountryCode: p.code, National: number}, nil
+}
+
+func parseInternational(number, defaultRegion string) (Number, error) {
+	for n := 1; n <= 3 && n < len(number); n++ {
+		code, national := number[:n], number[n:]
+		var candidates []plan
+		for _, p := range plans {
+			if p.code == code {
+				candidates = append(candidates, p)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		// a number of the default region over the other regions of its code
+		slices.SortStableFunc(candidates, func(a, b plan) int {
+			if a.region == strings.ToUpper(defaultRegion) {
+				return -1
+			}
+			if b.region == strings.ToUpper(defaultRegion) {
+				return 1
+			}
+			return 0
+		})
+		if !candidates[0].valid(national) {
+			return Number{}, ErrInvalid
+		}
+		return Number{Region: candidates[0].region, CountryCode: code, National: national}, nil
+	}
+	// a country without a plan gets the checks of E.164 alone: a country
+	// code doesn't start with 0 and the whole number has 7 to 15 digits
+	if len(number) < minE164Digits || len(number) > maxE164Digits || number[0] == '0' {
+		return Number{}, ErrInvalid
+	}
+	return Number{National: number}, nil
+}
+
+// valid checks the length of a national number, and for the north american
+// plan that neither the area code nor the exchange start with 0 or 1
+func (p plan) valid(national string) bool {
+	if !slices.Contains(p.lengths, len(national)) {
+		return false
+	}
+	if p.nanp && (national[0] < '2' || national[3] < '2') {
+		return false
+	}
+	return true
+}
+
+// E164 is the number as +, the country code and the national number, e.g.
+// +14155550123
+func (n Number) E164() string {
+	return "+" + n.CountryCode + n.National
+}
+
+// grouped splits the national number into the groups of its plan
+func (n Number) grouped() []string {
+	p, _ := lookup(n.Region)
+	var groups []string
+	rest := n.National
+	for _, size := range p.groups {
+		if len(rest) <= size {
+			break
+		}
+		groups = append(groups, rest[:size])
+		rest = rest[size:]
+	}
+	return append(groups, rest)
+}
+
+// FormatNational is the number as dialled within its region, e.g.
+// (415) 555-0123 or 07400 123456
+func (n Number) FormatNational() string {
+	p, ok := lookup(n.Region)
+	if !ok {
+		return n.E164()
+	}
+	groups := n.grouped()
+	if p.nanp && len(groups) == 3 {
+		return "(" + groups[0] + ") " + groups[1] + "-" + groups[2]
+	}
+	groups[0] = p.trunk + groups[0]
+	return strings.Join(groups, " ")
+}
+
+// FormatInternational is the number as dialled from abroad, e.g.
+// +1 415-555-0123 or +44 7400 123456
+func (n Number) FormatInternational() string {
+	p, ok := lookup(n.Region)
+	if !ok {
+		return n.E164()
+	}
+	sep := " "
+	if p.nanp {
+		sep = "-"
+	}
+	return "+" + n.CountryCode + " " + strings.Join(n.grouped(), sep)
+}
+
+// Format shows numbers of region in their national form and others in
+// their international one. numbers of countries without a plan are shown
+// in E.164.
+func (n Number) Format(region string) string {
+	if strings.EqualFold(n.Region, region) {
+		return n.FormatNational()
+	}
+	return n.FormatInternational()
+}
@@ -0,0 +1,63 @@
+package phone
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		input, region string
+		e164          string
+		err           error
+	}{
+		{"(415) 555-0123", "US", "+14155550123", nil},
+		{"1 415 555 0123", "US", "+14155550123", nil},
+		{"415.555.0123", "us", "+14155550123", nil},
+		{"+44 7400 123456", "US", "+447400123456", nil},
+		{"0044 7400 123456", "US", "+447400123456", nil},
+		{"07400 123456", "GB", "+447400123456", nil},
+		{"0912 345 6789", "IR", "+989123456789", nil},
+		{"01 23 45 67 89", "FR", "+33123456789", nil},
+		{"213214", "US", "", ErrInvalid},
+		{"(115) 555-0123", "US", "", ErrInvalid},
+		{"+1 415 555 01", "US", "", ErrInvalid},
+		{"555-CALL-NOW", "US", "", ErrInvalid},
+		{"+39 06 1234 5678", "US", "+390612345678", nil},
+		{"+86 138 0013 8000", "GB", "+8613800138000", nil},
+		{"+7 912 345 67 89", "US", "+79123456789", nil},
+		{"+999 12", "US", "", ErrInvalid},
+		{"+0 123 456 789", "US", "", ErrInvalid},
+		{"+81 12345678901234", "US", "", ErrInvalid},
+		{"07400 123456", "ZZ", "", ErrUnknownCountry},
+	} {
+		n, err := Parse(c.input, c.region)
+		if err != c.err {
+			t.Errorf("parsing %q in %s: got error %v, want %v", c.input, c.region, err, c.err)
+			continue
+		}
+		if err == nil && n.E164() != c.e164 {
+			t.Errorf("parsing %q in %s: got %s, want %s", c.input, c.region, n.E164(), c.e164)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, c := range []struct {
+		e164, region, want string
+	}{
+		{"+14155550123", "US", "(415) 555-0123"},
+		{"+14155550123", "GB", "+1 415-555-0123"},
+		{"+447400123456", "GB", "07400 123456"},
+		{"+447400123456", "US", "+44 7400 123456"},
+		{"+33123456789", "FR", "01 23 45 67 89"},
+		{"+989123456789", "IR", "0912 345 6789"},
+		{"+390612345678", "US", "+390612345678"},
+		{"+390612345678", "", "+390612345678"},
+	} {
+		n, err := Parse(c.e164, "")
+		if err != nil {
+			t.Fatalf("parsing %s: %v", c.e164, err)
+		}
+		if got := n.Format(c.region); got != c.want {
+			t.Errorf("formatting %s in %s: got %q, want %q", c.e164, c.region, got, c.want)
+		}
+	}
+}
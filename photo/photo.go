@@ -0,0 +1,147 @@
+// Package photo turns uploaded images into the contact photo and thumbnail
+// that get stored. images are decoded and re-encoded, which drops EXIF and
+// any other metadata.
+package photo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+const (
+	// upload size limit in bytes
+	MaxUploadSize = 10 << 20
+	// refuse images that would take too much memory to decode
+	MaxPixels = 40_000_000
+
+	// the photo fits in FullSize x FullSize, the thumbnail is a square
+	// ThumbSize wide
+	FullSize  = 512
+	ThumbSize = 96
+
+	ContentType = "image/jpeg"
+	quality     = 85
+)
+
+var (
+	ErrUnsupportedType = errors.New("photo must be a jpeg, png or gif")
+	ErrTooLarge        = errors.New("photo is too large")
+)
+
+type Photo struct {
+	Full  []byte
+	Thumb []byte
+}
+
+// Process checks an uploaded image and renders the photo and thumbnail as
+// jpeg, upright according to the EXIF orientation
+func Process(data []byte) (Photo, error) {
+	switch http.DetectContentType(data) {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return Photo{}, ErrUnsupportedType
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Photo{}, ErrUnsupportedType
+	}
+	if config.Width*config.Height > MaxPixels {
+		return Photo{}, ErrTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Photo{}, ErrUnsupportedType
+	}
+	img := orient(flatten(src), exifOrientation(data))
+
+	full, err := encode(resize(img, fit(img.Bounds().Size(), FullSize)))
+	if err != nil {
+		return Photo{}, err
+	}
+	thumb, err := encode(resize(squareCrop(img), image.Pt(ThumbSize, ThumbSize)))
+	if err != nil {
+		return Photo{}, err
+	}
+	return Photo{Full: full, Thumb: thumb}, nil
+}
+
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// flatten draws img on white, jpeg has no transparency
+func flatten(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+// fit scales size down to fit in limit x limit, keeping the aspect ratio. small
+// images are left alone.
+func fit(size image.Point, limit int) image.Point {
+	if size.X <= limit && size.Y <= limit {
+		return size
+	}
+	if size.X >= size.Y {
+		return image.Pt(limit, max(1, size.Y*limit/size.X))
+	}
+	return image.Pt(max(1, size.X*limit/size.Y), limit)
+}
+
+func squareCrop(img *image.RGBA) *image.RGBA {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x, y := (b.Dx()-side)/2, (b.Dy()-side)/2
+	return img.SubImage(image.Rect(x, y, x+side, y+side)).(*image.RGBA)
+}
+
+// resize scales img to size by averaging the source pixels that fall on
+// each destination pixel, which is good enough for shrinking
+func resize(img *image.RGBA, size image.Point) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	for y := 0; y < size.Y; y++ {
+		y0 := b.Min.Y + y*b.Dy()/size.Y
+		y1 := max(y0+1, b.Min.Y+(y+1)*b.Dy()/size.Y)
+		for x := 0; x < size.X; x++ {
+			x0 := b.Min.X + x*b.Dx()/size.X
+			x1 := max(x0+1, b.Min.X+(x+1)*b.Dx()/size.X)
+			var r, g, bl, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := img.RGBAAt(sx, sy)
+					r, g, bl, a = r+uint32(c.R), g+uint32(c.G), bl+uint32(c.B), a+uint32(c.A)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(bl / n), uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+// ID names a processed photo by its content, so a changed photo gets a new
+// URL and the old one can be cached forever
+func (p Photo) ID() string {
+	sum := sha256.Sum256(p.Full)
+	return hex.EncodeToString(sum[:16])
+}
+
+// blob keys of the photo and thumbnail of an ID
+func FullKey(id string) string  { return id + ".jpg" }
+func ThumbKey(id string) string { return id + "-thumb.jpg" }
@@ -0,0 +1,104 @@
+package photo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// red on the left half, blue on the right
+			if x < w/2 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	return img
+}
+
+func decode(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil || format != "jpeg" {
+		t.Fatalf("expected a jpeg, got %s, %v", format, err)
+	}
+	return img
+}
+
+// withOrientation inserts an EXIF block right after the start of the jpeg
+func withOrientation(jpg []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientationTag, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	segment := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(jpg[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	binary.Write(&out, binary.BigEndian, uint16(len(segment)+2))
+	out.Write(segment)
+	out.Write(jpg[2:])
+	return out.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	t.Run("shrinks to photo and square thumbnail", func(t *testing.T) {
+		var buf bytes.Buffer
+		png.Encode(&buf, testImage(1000, 500))
+		p, err := Process(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := decode(t, p.Full).Bounds().Size(); got != image.Pt(512, 256) {
+			t.Errorf("got photo of %v", got)
+		}
+		if got := decode(t, p.Thumb).Bounds().Size(); got != image.Pt(ThumbSize, ThumbSize) {
+			t.Errorf("got thumbnail of %v", got)
+		}
+	})
+
+	t.Run("applies and strips exif orientation", func(t *testing.T) {
+		var buf bytes.Buffer
+		jpeg.Encode(&buf, testImage(40, 20), nil)
+		upload := withOrientation(buf.Bytes(), 6)
+		if got := exifOrientation(upload); got != 6 {
+			t.Fatalf("read orientation %d, wanted 6", got)
+		}
+		p, err := Process(upload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		img := decode(t, p.Full)
+		if got := img.Bounds().Size(); got != image.Pt(20, 40) {
+			t.Errorf("got photo of %v, wanted it turned upright", got)
+		}
+		// a clockwise quarter turn puts the red left half on top
+		if r, _, b, _ := img.At(10, 5).RGBA(); r < b {
+			t.Errorf("expected red at the top after rotating")
+		}
+		if bytes.Contains(p.Full, []byte("Exif")) {
+			t.Errorf("expected exif to be stripped")
+		}
+	})
+
+	t.Run("rejects other files", func(t *testing.T) {
+		if _, err := Process([]byte("<svg xmlns='http://www.w3.org/2000/svg'></svg>")); err != ErrUnsupportedType {
+			t.Errorf("got %v, wanted ErrUnsupportedType", err)
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package contactapp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/blob"
+	"github.com/rezbow/contact-app/export"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/photo"
+	"github.com/rezbow/contact-app/views"
+)
+
+// request bodies are cut off past this, which leaves room for a photo and
+// the rest of its form
+const maxRequestBody = photo.MaxUploadSize + 1<<20
+
+// limitBody keeps clients from sending endless bodies. it has to run before
+// anything reads the form, like the csrf check.
+func limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// /contacts/{id}/photo
+func (s *Server) uploadPhoto(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	before, err := s.store.GetContact(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseMultipartForm(photo.MaxUploadSize); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			s.renderPhotoError(w, r, before, photo.ErrTooLarge)
+			return
+		}
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	file, _, err := r.FormFile(views.ContactFormPhoto)
+	if err != nil {
+		s.renderPhotoError(w, r, before, errors.New("choose a photo to upload"))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, photo.MaxUploadSize+1))
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(data) > photo.MaxUploadSize {
+		s.renderPhotoError(w, r, before, photo.ErrTooLarge)
+		return
+	}
+	processed, err := photo.Process(data)
+	if err != nil {
+		s.renderPhotoError(w, r, before, err)
+		return
+	}
+	photoID := processed.ID()
+	if err := s.blobs.Put(photo.FullKey(photoID), processed.Full); err != nil {
+		log.Println(err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if err := s.blobs.Put(photo.ThumbKey(photoID), processed.Thumb); err != nil {
+		log.Println(err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	s.setPhoto(w, r, before, photoID)
+}
+
+// old photos stay in the blob store, revisions may still point at them
+func (s *Server) deletePhoto(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	before, err := s.store.GetContact(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.setPhoto(w, r, before, "")
+}
+
+func (s *Server) setPhoto(w http.ResponseWriter, r *http.Request, before models.Contact, photoID string) {
+	after := before
+	after.Photo = photoID
+	if err := s.store.EditContact(after); err != nil {
+		switch err {
+		case ErrEditConflict:
+			http.Error(w, err.Error(), http.StatusConflict)
+		case ErrNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	s.recordChange(r, audit.ActionEdit, before, after)
+	s.recordRevision(r, before, after, 0)
+	redirect(w, r, fmt.Sprintf("/contacts/%d/edit", before.ID))
+}
+
+func (s *Server) renderPhotoError(w http.ResponseWriter, r *http.Request, contact models.Contact, err error) {
+	form := views.ContactFormFromContact(&contact)
+	form.Errors.Set(views.ContactFormPhoto, err.Error())
+	s.renderEdit(w, r, form)
+}
+
+// /photos/{key} never changes, keys are derived from the content
+func (s *Server) getPhoto(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	data, err := s.blobs.Get(key)
+	if err != nil {
+		switch err {
+		case blob.ErrNotFound, blob.ErrInvalidKey:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", photo.ContentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", `"`+key+`"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, key, time.Time{}, bytes.NewReader(data))
+}
+
+// contactPhoto is the jpeg of the contact's photo, nil when it has none
+func (s *Server) contactPhoto(c models.Contact) []byte {
+	if c.Photo == "" {
+		return nil
+	}
+	data, err := s.blobs.Get(photo.FullKey(c.Photo))
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	return data
+}
+
+// /contacts/{id}/vcard
+func (s *Server) getVCard(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	contact, err := s.store.GetContact(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/vcard")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="contact-%d.vcf"`, id))
+	if err := export.WriteVCard(w, []models.Contact{contact}, s.contactPhoto); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,131 @@
+package contactapp
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rezbow/contact-app/blob"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/photo"
+	"github.com/rezbow/contact-app/views"
+)
+
+func uploadRequest(t *testing.T, path string, data []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile(views.ContactFormPhoto, "me.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(data)
+	mw.Close()
+	req, _ := http.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestPhotos(t *testing.T) {
+	store := NewinMemoryStore()
+	blobs := blob.NewMemory()
+	server := NewContactServer(store, WithBlobStore(blobs))
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		req.Header.Set(views.CSRFHeaderName, session.CSRFToken)
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+
+	t.Run("contacts without a photo get initials", func(t *testing.T) {
+		body := serve(newGetRequest("/contacts/1")).Body.String()
+		if !strings.Contains(body, `aria-hidden="true">JJ</span>`) {
+			t.Errorf("expected an initials avatar")
+		}
+	})
+
+	t.Run("upload stores a photo and thumbnail", func(t *testing.T) {
+		var buf bytes.Buffer
+		png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 800, 600)))
+		res := serve(uploadRequest(t, "/contacts/1/photo", buf.Bytes()))
+		assertRedirect(t, res, "/contacts/1/edit")
+
+		contact, _ := store.GetContact(1)
+		if contact.Photo == "" {
+			t.Fatalf("expected the contact to have a photo")
+		}
+		for _, key := range []string{photo.FullKey(contact.Photo), photo.ThumbKey(contact.Photo)} {
+			if _, err := blobs.Get(key); err != nil {
+				t.Errorf("expected blob %s, got %v", key, err)
+			}
+		}
+		rows := serve(newGetRequest("/contacts")).Body.String()
+		if !strings.Contains(rows, `src="/photos/`+photo.ThumbKey(contact.Photo)+`"`) {
+			t.Errorf("expected the thumbnail in the contact list")
+		}
+	})
+
+	t.Run("photos are served with caching headers", func(t *testing.T) {
+		contact, _ := store.GetContact(1)
+		res := serve(newGetRequest("/photos/" + photo.ThumbKey(contact.Photo)))
+		assertCode(t, res.Code, http.StatusOK)
+		if res.Header().Get("Content-Type") != "image/jpeg" || !strings.Contains(res.Header().Get("Cache-Control"), "immutable") {
+			t.Errorf("got headers %v", res.Header())
+		}
+		req := newGetRequest("/photos/" + photo.ThumbKey(contact.Photo))
+		req.Header.Set("If-None-Match", res.Header().Get("ETag"))
+		assertCode(t, serve(req).Code, http.StatusNotModified)
+		assertCode(t, serve(newGetRequest("/photos/..%2Fserver.go")).Code, http.StatusNotFound)
+	})
+
+	t.Run("vcard embeds the photo", func(t *testing.T) {
+		body := serve(newGetRequest("/contacts/1/vcard")).Body.String()
+		if !strings.Contains(body, "PHOTO;ENCODING=b;TYPE=JPEG:") || !strings.Contains(body, "FN:Jack Jackson") {
+			t.Errorf("got vcard %s", body)
+		}
+	})
+
+	t.Run("other files are rejected", func(t *testing.T) {
+		before, _ := store.GetContact(1)
+		res := serve(uploadRequest(t, "/contacts/1/photo", []byte("just some text")))
+		assertCode(t, res.Code, http.StatusOK)
+		if !strings.Contains(res.Body.String(), photo.ErrUnsupportedType.Error()) {
+			t.Errorf("expected an error on the edit page")
+		}
+		if after, _ := store.GetContact(1); after.Photo != before.Photo {
+			t.Errorf("expected the photo to stay")
+		}
+	})
+
+	t.Run("editing keeps the photo, removing drops it", func(t *testing.T) {
+		contact, _ := store.GetContact(1)
+		assertRedirect(t, serve(editContactRequest(contact)), "/contacts/1")
+		if after, _ := store.GetContact(1); after.Photo != contact.Photo {
+			t.Errorf("editing lost the photo")
+		}
+		req, _ := http.NewRequest(http.MethodDelete, "/contacts/1/photo", nil)
+		assertRedirect(t, serve(req), "/contacts/1/edit")
+		if after, _ := store.GetContact(1); after.Photo != "" {
+			t.Errorf("expected the photo to be removed")
+		}
+	})
+}
+
+func TestPhotoEditConflict(t *testing.T) {
+	store := &StubContactStore{
+		contacts: []models.Contact{{ID: 1, FirstName: "Chris", LastName: "Jackson", Photo: "abc", Version: 1}},
+		editErr:  ErrEditConflict,
+	}
+	server := NewContactServer(store)
+	req, _ := http.NewRequest(http.MethodDelete, "/contacts/1/photo", nil)
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, withSession(req, signedIn(server)))
+	assertCode(t, res.Code, http.StatusConflict)
+}
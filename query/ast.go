@@ -0,0 +1,113 @@
+// Package query parses the search language of the contact list, e.g.
+//
+//	tag:customer email:*@acme.com -company:acme created:>2026-01-01
+//
+// words next to each other must all match, OR between them lets either do,
+// a leading - or NOT negates and parentheses group. AND binds tighter than
+// OR. a word is searched like a plain search, a "quoted phrase" has to
+// appear as is, and field:value looks in one field only. values with * are
+// patterns for the whole field value, others only have to appear in it.
+package query
+
+import (
+	"fmt"
+	"time"
+)
+
+// Node of the syntax tree of a query
+type Node interface {
+	fmt.Stringer
+	node()
+}
+
+type And struct{ Left, Right Node }
+
+type Or struct{ Left, Right Node }
+
+type Not struct{ X Node }
+
+// Text is a word searched everywhere, or a phrase when quoted
+type Text struct {
+	Value  string
+	Quoted bool
+}
+
+// Op compares dates
+type Op string
+
+const (
+	OpEqual   Op = "="
+	OpLess    Op = "<"
+	OpLessEq  Op = "<="
+	OpGreater Op = ">"
+	OpGreatEq Op = ">="
+)
+
+// Field restricts a search to one field, see Fields
+type Field struct {
+	Name  string
+	Op    Op
+	Value string
+	// the value of date fields
+	Date time.Time
+}
+
+func (And) node()   {}
+func (Or) node()    {}
+func (Not) node()   {}
+func (Text) node()  {}
+func (Field) node() {}
+
+func (n And) String() string { return "(" + n.Left.String() + " AND " + n.Right.String() + ")" }
+func (n Or) String() string  { return "(" + n.Left.String() + " OR " + n.Right.String() + ")" }
+func (n Not) String() string { return "-" + n.X.String() }
+
+func (n Text) String() string {
+	if n.Quoted {
+		return fmt.Sprintf("%q", n.Value)
+	}
+	return n.Value
+}
+
+func (n Field) String() string {
+	op := string(n.Op)
+	if n.Op == OpEqual {
+		op = ""
+	}
+	return n.Name + ":" + op + n.Value
+}
+
+type fieldKind int
+
+const (
+	textField fieldKind = iota
+	dateField
+)
+
+// Fields that can be searched on their own
+var Fields = map[string]fieldKind{
+	"name":      textField,
+	"first":     textField,
+	"last":      textField,
+	"email":     textField,
+	"phone":     textField,
+	"company":   textField,
+	"title":     textField,
+	"notes":     textField,
+	"tag":       textField,
+	"created":   dateField,
+	"updated":   dateField,
+	"contacted": dateField,
+}
+
+// Query is a parsed query, the zero Query matches everything
+type Query struct {
+	Root Node
+}
+
+func (q *Query) String() string {
+	if q.Root == nil {
+		return ""
+	}
+	return q.Root.String()
+}
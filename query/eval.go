@@ -0,0 +1,166 @@
+package query
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+// Matches reports whether c matches the query, making a Query a
+// models.Condition for the in memory store
+func (q *Query) Matches(c models.Contact) bool {
+	return q.Root == nil || matches(q.Root, c)
+}
+
+func matches(n Node, c models.Contact) bool {
+	switch n := n.(type) {
+	case And:
+		return matches(n.Left, c) && matches(n.Right, c)
+	case Or:
+		return matches(n.Left, c) || matches(n.Right, c)
+	case Not:
+		return !matches(n.X, c)
+	case Text:
+		if n.Quoted {
+			return containsPhrase(c, n.Value)
+		}
+		return models.MatchesQuery(c, n.Value)
+	case Field:
+		return matchesField(n, c)
+	}
+	return false
+}
+
+// containsPhrase reports whether the words of phrase follow each other in
+// one of the searchable fields of c
+func containsPhrase(c models.Contact, phrase string) bool {
+	words := " " + strings.Join(models.Tokenize(phrase), " ") + " "
+	for _, field := range models.ContactSearchFields(c) {
+		if strings.Contains(" "+strings.Join(field.Tokens, " ")+" ", words) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldValues are what a field: searches in
+func fieldValues(name string, c models.Contact) []string {
+	switch name {
+	case "name":
+		return []string{c.FirstName + " " + c.LastName}
+	case "first":
+		return []string{c.FirstName}
+	case "last":
+		return []string{c.LastName}
+	case "email":
+		var emails []string
+		for _, email := range c.Emails {
+			emails = append(emails, email.Address)
+		}
+		return emails
+	case "phone":
+		var phones []string
+		for _, phone := range c.Phones {
+			phones = append(phones, models.PhoneDigits(phone)...)
+		}
+		return phones
+	case "company":
+		return []string{c.Company}
+	case "title":
+		return []string{c.JobTitle}
+	case "notes":
+		return []string{c.Notes}
+	case "tag":
+		return c.Tags
+	}
+	return nil
+}
+
+func fieldDate(name string, c models.Contact) time.Time {
+	switch name {
+	case "created":
+		return c.CreatedAt
+	case "updated":
+		return c.UpdatedAt
+	case "contacted":
+		return c.LastContacted
+	}
+	return time.Time{}
+}
+
+func matchesField(f Field, c models.Contact) bool {
+	if Fields[f.Name] == dateField {
+		t := fieldDate(f.Name, c)
+		return !t.IsZero() && compareDate(models.DateOf(t.Local()), f.Op, f.Date)
+	}
+	pattern := fieldPattern(f)
+	for _, value := range fieldValues(f.Name, c) {
+		if pattern.match(models.Fold(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareDate(date time.Time, op Op, want time.Time) bool {
+	switch op {
+	case OpLess:
+		return date.Before(want)
+	case OpLessEq:
+		return !date.After(want)
+	case OpGreater:
+		return date.After(want)
+	case OpGreatEq:
+		return !date.Before(want)
+	}
+	return date.Equal(want)
+}
+
+// pattern is a folded field value split at its *s. a value without * only
+// has to appear somewhere, tags have to match whole.
+type pattern struct {
+	parts []string
+	whole bool
+}
+
+func fieldPattern(f Field) pattern {
+	value := models.Fold(f.Value)
+	switch f.Name {
+	case "phone":
+		value = strings.Map(func(r rune) rune {
+			if r == '*' || r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, value)
+	case "tag":
+		value = models.NormalizeTag(value)
+	}
+	return pattern{
+		parts: strings.Split(value, "*"),
+		whole: strings.Contains(value, "*") || f.Name == "tag",
+	}
+}
+
+func (p pattern) match(s string) bool {
+	if !p.whole {
+		return strings.Contains(s, p.parts[0])
+	}
+	first, last := p.parts[0], p.parts[len(p.parts)-1]
+	if !strings.HasPrefix(s, first) {
+		return false
+	}
+	s = s[len(first):]
+	if len(p.parts) == 1 {
+		return s == ""
+	}
+	for _, part := range p.parts[1 : len(p.parts)-1] {
+		idx := strings.Index(s, part)
+		if idx == -1 {
+			return false
+		}
+		s = s[idx+len(part):]
+	}
+	return strings.HasSuffix(s, last)
+}
@@ -0,0 +1,83 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestMatches(t *testing.T) {
+	contact := models.Contact{
+		FirstName: "Mary Ann", LastName: "Smith",
+		Emails:    []models.Email{{Address: "mary@acme.com"}},
+		Phones:    []models.Phone{{Number: "+1 (555) 123-4567"}},
+		Company:   "Acme Corp",
+		Notes:     "Prefers email",
+		Tags:      []string{"customer", "vip"},
+		CreatedAt: time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local),
+	}
+	for input, want := range map[string]bool{
+		"":                                    true,
+		"mary":                                true,
+		"MARY smi":                            true,
+		"mary jones":                          false,
+		"mary OR jones":                       true,
+		`"mary ann"`:                          true,
+		`"ann mary"`:                          false,
+		`name:"ann smith"`:                    true,
+		"tag:customer":                        true,
+		"tag:cust":                            false,
+		"tag:cust*":                           true,
+		"email:*@acme.com":                    true,
+		"email:*@acme.org":                    false,
+		"email:acme":                          true,
+		"-company:acme":                       false,
+		"-company:globex":                     true,
+		"phone:555123":                        true,
+		"phone:*4567":                         true,
+		"created:>2026-01-01":                 true,
+		"created:2026-03-10":                  true,
+		"created:<2026-03-10":                 false,
+		"contacted:>2000-01-01":               false,
+		"tag:vip -(company:acme notes:sms)":   true,
+		"tag:vip -(company:acme notes:email)": false,
+	} {
+		q, err := Parse(input)
+		if err != nil {
+			t.Fatalf("parsing %q: %v", input, err)
+		}
+		if got := q.Matches(contact); got != want {
+			t.Errorf("%q matching got %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	q, _ := Parse(`jack tag:Customer "new york" -tag:vip doe`)
+	filter := q.Plan(models.ContactFilter{})
+	if filter.Query != "jack doe" || filter.Tag != "customer" {
+		t.Errorf("got filter %+v", filter)
+	}
+	if got := filter.Condition.(*Query).String(); got != `("new york" AND -tag:vip)` {
+		t.Errorf("got condition %s", got)
+	}
+	if q.Plain() {
+		t.Errorf("expected a structured query")
+	}
+
+	q, _ = Parse("jack doe")
+	if filter := q.Plan(models.ContactFilter{}); filter.Query != "jack doe" || filter.Condition != nil || !q.Plain() {
+		t.Errorf("expected plain words to stay a plain search, got %+v", filter)
+	}
+
+	q, _ = Parse("jack OR doe")
+	if filter := q.Plan(models.ContactFilter{}); filter.Query != "" || filter.Condition == nil {
+		t.Errorf("expected an OR to be left to the condition, got %+v", filter)
+	}
+
+	q, _ = Parse("tag:golf")
+	if filter := q.Plan(models.ContactFilter{Tag: "vip"}); filter.Tag != "vip" || filter.Condition == nil {
+		t.Errorf("expected the tag of the filter to stay, got %+v", filter)
+	}
+}
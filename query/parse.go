@@ -0,0 +1,236 @@
+package query
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ParseError points at what's wrong with a query
+type ParseError struct {
+	// rune offset into the query
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Msg, e.Pos+1)
+}
+
+type parser struct {
+	input []rune
+	pos   int
+}
+
+// Parse reads a query, see the package documentation for the syntax
+func Parse(s string) (*Query, error) {
+	p := &parser{input: []rune(s)}
+	p.skipSpace()
+	if p.eof() {
+		return &Query{}, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+	return &Query{Root: root}, nil
+}
+
+func (p *parser) errorf(format string, args ...any) *ParseError {
+	return &ParseError{Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// keyword consumes word if it is next, on its own
+func (p *parser) keyword(word string) bool {
+	end := p.pos + len(word)
+	if end > len(p.input) || string(p.input[p.pos:end]) != word {
+		return false
+	}
+	if end < len(p.input) && !unicode.IsSpace(p.input[end]) && p.input[end] != '(' {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.keyword("OR") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.eof() || p.input[p.pos] == ')' {
+			return left, nil
+		}
+		start := p.pos
+		if p.keyword("OR") {
+			p.pos = start
+			return left, nil
+		}
+		p.keyword("AND")
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, p.errorf("missing search term")
+	}
+	negated := p.input[p.pos] == '-'
+	if negated {
+		p.pos++
+	}
+	if negated || p.keyword("NOT") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	switch p.input[p.pos] {
+	case '(':
+		open := p.pos
+		p.pos++
+		p.skipSpace()
+		if !p.eof() && p.input[p.pos] == ')' {
+			return nil, p.errorf("empty parentheses")
+		}
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.eof() || p.input[p.pos] != ')' {
+			return nil, &ParseError{Pos: open, Msg: "missing closing parenthesis"}
+		}
+		p.pos++
+		return x, nil
+	case ')':
+		return nil, p.errorf("unexpected %q", ')')
+	case '"':
+		value, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return Text{Value: value, Quoted: true}, nil
+	}
+	start := p.pos
+	word := p.word()
+	name, value, ok := strings.Cut(word, ":")
+	if !ok || strings.IndexFunc(name, func(r rune) bool { return !unicode.IsLetter(r) }) != -1 || name == "" {
+		return Text{Value: word}, nil
+	}
+	kind, known := Fields[strings.ToLower(name)]
+	if !known {
+		return nil, &ParseError{Pos: start, Msg: fmt.Sprintf("unknown field %q, try one of %s", name, strings.Join(slices.Sorted(maps.Keys(Fields)), ", "))}
+	}
+	return p.field(start, strings.ToLower(name), value, kind)
+}
+
+// word reads up to the next space, parenthesis or quote. a quote right after
+// a colon quotes the value of a field, e.g. name:"Mary Ann".
+func (p *parser) word() string {
+	start := p.pos
+	for !p.eof() {
+		r := p.input[p.pos]
+		if unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' {
+			break
+		}
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
+
+// quoted reads a "string", \" and \\ escape
+func (p *parser) quoted() (string, error) {
+	open := p.pos
+	p.pos++
+	var b strings.Builder
+	for !p.eof() {
+		r := p.input[p.pos]
+		p.pos++
+		switch {
+		case r == '"':
+			return b.String(), nil
+		case r == '\\' && !p.eof():
+			b.WriteRune(p.input[p.pos])
+			p.pos++
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return "", &ParseError{Pos: open, Msg: "missing closing quote"}
+}
+
+func (p *parser) field(start int, name, value string, kind fieldKind) (Node, error) {
+	if value == "" && !p.eof() && p.input[p.pos] == '"' {
+		quoted, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		value = quoted
+	}
+	if value == "" {
+		return nil, &ParseError{Pos: start, Msg: fmt.Sprintf("missing value for %s", name)}
+	}
+	f := Field{Name: name, Op: OpEqual, Value: value}
+	if kind != dateField {
+		return f, nil
+	}
+	for _, op := range []Op{OpGreatEq, OpLessEq, OpGreater, OpLess, OpEqual} {
+		if rest, ok := strings.CutPrefix(value, string(op)); ok {
+			f.Op, f.Value = op, rest
+			break
+		}
+	}
+	date, err := time.Parse(time.DateOnly, f.Value)
+	if err != nil {
+		return nil, &ParseError{Pos: start, Msg: fmt.Sprintf("%s needs a date like 2026-01-31", name)}
+	}
+	f.Date = date
+	return f, nil
+}
@@ -0,0 +1,65 @@
+package query
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for input, want := range map[string]string{
+		"":                         "",
+		"jack":                     "jack",
+		"jack doe":                 "(jack AND doe)",
+		"jack AND doe":             "(jack AND doe)",
+		"a OR b c":                 "(a OR (b AND c))",
+		"a b OR c":                 "((a AND b) OR c)",
+		"a (b OR c)":               "(a AND (b OR c))",
+		"-a b":                     "(-a AND b)",
+		"NOT a OR b":               "(-a OR b)",
+		"-(a OR b)":                "-(a OR b)",
+		"--a":                      "--a",
+		"or and":                   "(or AND and)",
+		`"mary ann" smith`:         `("mary ann" AND smith)`,
+		`name:"Mary Ann"`:          "name:Mary Ann",
+		`"say \"hi\""`:             `"say \"hi\""`,
+		"tag:customer":             "tag:customer",
+		"TAG:customer":             "tag:customer",
+		"email:*@acme.com":         "email:*@acme.com",
+		"-company:acme":            "-company:acme",
+		"created:>2026-01-01":      "created:>2026-01-01",
+		"created:<=2026-01-01":     "created:<=2026-01-01",
+		"contacted:2026-01-01":     "contacted:2026-01-01",
+		"10:30":                    "10:30",
+		"+33 (6) 12-34":            "((+33 AND 6) AND 12-34)",
+		"tag:a tag:b OR -email:*x": "((tag:a AND tag:b) OR -email:*x)",
+	} {
+		q, err := Parse(input)
+		if err != nil {
+			t.Errorf("parsing %q: %v", input, err)
+			continue
+		}
+		if got := q.String(); got != want {
+			t.Errorf("parsing %q got %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for input, want := range map[string]ParseError{
+		"(a OR b":           {Pos: 0, Msg: "missing closing parenthesis"},
+		"a )":               {Pos: 2, Msg: `unexpected ')'`},
+		"a OR":              {Pos: 4, Msg: "missing search term"},
+		"-":                 {Pos: 1, Msg: "missing search term"},
+		"()":                {Pos: 1, Msg: "empty parentheses"},
+		`a "b`:              {Pos: 2, Msg: "missing closing quote"},
+		"tag:":              {Pos: 0, Msg: "missing value for tag"},
+		"created:yesterday": {Pos: 0, Msg: "created needs a date like 2026-01-31"},
+		"x foo:bar":         {Pos: 2, Msg: `unknown field "foo", try one of company, contacted, created, email, first, last, name, notes, phone, tag, title, updated`},
+	} {
+		_, err := Parse(input)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || *parseErr != want {
+			t.Errorf("parsing %q got %v, want %v", input, err, &want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package query
+
+import (
+	"strings"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+// conjuncts flattens the ANDs at the top of n
+func conjuncts(n Node) []Node {
+	if and, ok := n.(And); ok {
+		return append(conjuncts(and.Left), conjuncts(and.Right)...)
+	}
+	return []Node{n}
+}
+
+func and(nodes []Node) Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	n := nodes[0]
+	for _, next := range nodes[1:] {
+		n = And{Left: n, Right: next}
+	}
+	return n
+}
+
+// Plan adds the query to filter for a store to run: the words that must
+// match go in Query, where the search index ranks them, a tag that must
+// match goes in Tag unless filter has one, and whatever is left becomes the
+// Condition checked on each contact
+func (q *Query) Plan(filter models.ContactFilter) models.ContactFilter {
+	var (
+		words []string
+		rest  []Node
+	)
+	if q.Root == nil {
+		return filter
+	}
+	for _, n := range conjuncts(q.Root) {
+		switch n := n.(type) {
+		case Text:
+			if !n.Quoted {
+				words = append(words, n.Value)
+				continue
+			}
+		case Field:
+			if n.Name == "tag" && filter.Tag == "" && !strings.Contains(n.Value, "*") {
+				filter.Tag = models.NormalizeTag(n.Value)
+				continue
+			}
+		}
+		rest = append(rest, n)
+	}
+	filter.Query = strings.Join(words, " ")
+	if len(rest) > 0 {
+		filter.Condition = &Query{Root: and(rest)}
+	}
+	return filter
+}
+
+// Plain reports whether the query is only words, as a plain search is
+func (q *Query) Plain() bool {
+	if q.Root == nil {
+		return true
+	}
+	for _, n := range conjuncts(q.Root) {
+		if text, ok := n.(Text); !ok || text.Quoted {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package query
+
+import (
+	"strings"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+// SQL plans the query as a WHERE clause with ? placeholders, for a store
+// with this schema:
+//
+//	contacts(id, first_name, last_name, company, job_title, notes, created_at, updated_at, last_contacted)
+//	contact_emails(contact_id, address)
+//	contact_phones(contact_id, digits)
+//	contact_tags(contact_id, tag)
+//
+// text is compared with LIKE, so columns need a case and accent insensitive
+// collation to match like the in memory store. dates are passed as
+// "2006-01-02" strings and compared to the date of the timestamp columns.
+// words search names, company, job title and notes by the start of their
+// words and emails anywhere, close to the search index but without phone
+// numbers.
+func (q *Query) SQL() (string, []any) {
+	if q.Root == nil {
+		return "1 = 1", nil
+	}
+	var args []any
+	return sqlNode(q.Root, &args), args
+}
+
+var sqlColumns = map[string]string{
+	"name":      "contacts.first_name || ' ' || contacts.last_name",
+	"first":     "contacts.first_name",
+	"last":      "contacts.last_name",
+	"company":   "contacts.company",
+	"title":     "contacts.job_title",
+	"notes":     "contacts.notes",
+	"created":   "contacts.created_at",
+	"updated":   "contacts.updated_at",
+	"contacted": "contacts.last_contacted",
+}
+
+// fields kept in their own table, by table and column
+var sqlTables = map[string][2]string{
+	"email": {"contact_emails", "address"},
+	"phone": {"contact_phones", "digits"},
+	"tag":   {"contact_tags", "tag"},
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func sqlNode(n Node, args *[]any) string {
+	switch n := n.(type) {
+	case And:
+		return "(" + sqlNode(n.Left, args) + " AND " + sqlNode(n.Right, args) + ")"
+	case Or:
+		return "(" + sqlNode(n.Left, args) + " OR " + sqlNode(n.Right, args) + ")"
+	case Not:
+		return "NOT " + sqlNode(n.X, args)
+	case Text:
+		return sqlText(n, args)
+	case Field:
+		return sqlField(n, args)
+	}
+	return "1 = 0"
+}
+
+// sqlText matches every word of an unquoted text at the start of a word of a
+// column, and a phrase anywhere
+func sqlText(n Text, args *[]any) string {
+	words := models.Tokenize(n.Value)
+	if n.Quoted {
+		words = []string{strings.Join(words, " ")}
+	}
+	if len(words) == 0 {
+		return "1 = 1"
+	}
+	var conditions []string
+	for _, word := range words {
+		word = likeEscaper.Replace(word)
+		var columns []string
+		for _, column := range []string{"first_name", "last_name", "company", "job_title", "notes"} {
+			columns = append(columns, "contacts."+column+` LIKE ? ESCAPE '\'`, "contacts."+column+` LIKE ? ESCAPE '\'`)
+			*args = append(*args, word+"%", "% "+word+"%")
+		}
+		columns = append(columns, `EXISTS (SELECT 1 FROM contact_emails WHERE contact_emails.contact_id = contacts.id AND contact_emails.address LIKE ? ESCAPE '\')`)
+		*args = append(*args, "%"+word+"%")
+		conditions = append(conditions, "("+strings.Join(columns, " OR ")+")")
+	}
+	return "(" + strings.Join(conditions, " AND ") + ")"
+}
+
+func sqlField(f Field, args *[]any) string {
+	if Fields[f.Name] == dateField {
+		*args = append(*args, f.Date.Format("2006-01-02"))
+		return "DATE(" + sqlColumns[f.Name] + ") " + string(f.Op) + " ?"
+	}
+	p := fieldPattern(f)
+	for i, part := range p.parts {
+		p.parts[i] = likeEscaper.Replace(part)
+	}
+	like := strings.Join(p.parts, "%")
+	if !p.whole {
+		like = "%" + like + "%"
+	}
+	*args = append(*args, like)
+	if table, ok := sqlTables[f.Name]; ok {
+		return "EXISTS (SELECT 1 FROM " + table[0] + " WHERE " + table[0] + ".contact_id = contacts.id AND " +
+			table[0] + "." + table[1] + ` LIKE ? ESCAPE '\')`
+	}
+	return sqlColumns[f.Name] + ` LIKE ? ESCAPE '\'`
+}
@@ -0,0 +1,46 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSQL(t *testing.T) {
+	for _, c := range []struct {
+		input string
+		where string
+		args  []any
+	}{
+		{"", "1 = 1", nil},
+		{
+			"tag:customer -company:ac%me",
+			`(EXISTS (SELECT 1 FROM contact_tags WHERE contact_tags.contact_id = contacts.id AND contact_tags.tag LIKE ? ESCAPE '\') AND NOT contacts.company LIKE ? ESCAPE '\')`,
+			[]any{"customer", `%ac\%me%`},
+		},
+		{
+			"email:*@acme.com OR created:>=2026-01-01",
+			`(EXISTS (SELECT 1 FROM contact_emails WHERE contact_emails.contact_id = contacts.id AND contact_emails.address LIKE ? ESCAPE '\') OR DATE(contacts.created_at) >= ?)`,
+			[]any{"%@acme.com", "2026-01-01"},
+		},
+		{
+			`name:"Mary Ann"`,
+			`contacts.first_name || ' ' || contacts.last_name LIKE ? ESCAPE '\'`,
+			[]any{"%mary ann%"},
+		},
+	} {
+		q, err := Parse(c.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		where, args := q.SQL()
+		if where != c.where || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("planning %q got\n%s %v\nwant\n%s %v", c.input, where, args, c.where, c.args)
+		}
+	}
+
+	q, _ := Parse("jack")
+	where, args := q.SQL()
+	if len(args) != 11 || where[:1] != "(" {
+		t.Errorf("expected each word to search the text columns and emails, got %s %v", where, args)
+	}
+}
@@ -0,0 +1,121 @@
+package contactapp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+const maxRelatedSearchResults = 10
+
+// related lists the relationships of a contact as seen from it. contacts in
+// the trash are left out until they are restored.
+func (s *Server) related(contactID int) []views.Related {
+	var related []views.Related
+	for _, relationship := range s.relations.Relationships(contactID) {
+		otherID, relationType := relationship.From(contactID)
+		other, err := s.store.GetContact(otherID)
+		if err != nil {
+			continue
+		}
+		t, _ := models.LookupRelationType(relationType)
+		related = append(related, views.Related{
+			RelationshipID: relationship.ID,
+			Type:           t,
+			Mutual:         relationship.Mutual,
+			Contact:        other,
+		})
+	}
+	return related
+}
+
+// /contacts/{id}/relationships/search?q=ja feeds the relationship picker
+func (s *Server) searchRelated(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	var candidates []models.Contact
+	if q := r.URL.Query().Get("q"); q != "" {
+		page := models.Page{Number: 1, Size: maxRelatedSearchResults + 1}
+		for _, contact := range s.store.FilterContacts(models.ContactFilter{Query: q}, page).Contacts {
+			if contact.ID != id && len(candidates) < maxRelatedSearchResults {
+				candidates = append(candidates, contact)
+			}
+		}
+	}
+	renderPartial(w, r.Context(), views.RelatedCandidates(candidates))
+}
+
+// /contacts/{id}/relationships
+func (s *Server) addRelationship(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	contact, err := s.store.GetContact(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	form := views.RelationshipFormFromRequest(r)
+	if form.Valid() {
+		if _, err := s.store.GetContact(form.RelatedID); err != nil || form.RelatedID == id {
+			form.Errors.Set(views.RelationshipFormRelated, "pick another contact")
+		}
+	}
+	if len(form.Errors) == 0 {
+		_, err = s.relations.AddRelationship(models.Relationship{
+			ContactID: id,
+			RelatedID: form.RelatedID,
+			Type:      form.Type,
+			Mutual:    form.Mutual,
+		})
+		switch err {
+		case nil:
+			redirect(w, r, fmt.Sprintf("/contacts/%d/edit", id))
+			return
+		case ErrDuplicateRelationship:
+			form.Errors.Set(views.RelationshipFormRelated, err.Error())
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+	edit := views.ContactFormFromContact(&contact)
+	edit.Relationship = form
+	s.renderEdit(w, r, edit)
+}
+
+// /contacts/{id}/relationships/{rel}
+func (s *Server) deleteRelationship(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	relID, _ := strconv.Atoi(r.PathValue("rel"))
+	found := false
+	for _, relationship := range s.relations.Relationships(id) {
+		found = found || relationship.ID == relID
+	}
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.relations.DeleteRelationship(relID); err != nil {
+		switch err {
+		case ErrRelationshipNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	renderString(w, "")
+}
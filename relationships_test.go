@@ -0,0 +1,83 @@
+package contactapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/rezbow/contact-app/views"
+)
+
+func TestRelationships(t *testing.T) {
+	store := NewinMemoryStore()
+	relations := NewInMemoryRelationshipStore()
+	server := NewContactServer(store, WithRelationshipStore(relations))
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	relate := func(id string, f url.Values) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/contacts/"+id+"/relationships", strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+	detail := func(id string) string {
+		return serve(newGetRequest("/contacts/" + id)).Body.String()
+	}
+
+	t.Run("picker searches other contacts", func(t *testing.T) {
+		body := serve(newGetRequest("/contacts/1/relationships/search?q=J")).Body.String()
+		if strings.Contains(body, `value="1"`) || !strings.Contains(body, `value="2"`) {
+			t.Errorf("expected contact 2 but not contact 1 itself, got %s", body)
+		}
+	})
+
+	t.Run("one-way relationship shows on one side", func(t *testing.T) {
+		res := relate("1", url.Values{views.RelationshipFormType: {"manager"}, views.RelationshipFormRelated: {"2"}})
+		assertRedirect(t, res, "/contacts/1/edit")
+		if body := detail("1"); !strings.Contains(body, "Manager: ") || !strings.Contains(body, "John") {
+			t.Errorf("expected John as manager on Jack's page")
+		}
+		if strings.Contains(detail("2"), "Related") {
+			t.Errorf("expected nothing on John's page")
+		}
+		res = relate("1", url.Values{views.RelationshipFormType: {"manager"}, views.RelationshipFormRelated: {"2"}})
+		if !strings.Contains(res.Body.String(), ErrDuplicateRelationship.Error()) {
+			t.Errorf("expected the duplicate to be rejected")
+		}
+	})
+
+	t.Run("mutual relationship shows the inverse on the other side", func(t *testing.T) {
+		res := relate("2", url.Values{views.RelationshipFormType: {"assistant"}, views.RelationshipFormRelated: {"3"}, views.RelationshipFormMutual: {"true"}})
+		assertRedirect(t, res, "/contacts/2/edit")
+		if !strings.Contains(detail("3"), "Assists them: ") {
+			t.Errorf("expected the inverse relationship on the assistant's page")
+		}
+	})
+
+	t.Run("invalid picks are rejected", func(t *testing.T) {
+		res := relate("1", url.Values{views.RelationshipFormType: {"nemesis"}, views.RelationshipFormRelated: {"1"}})
+		assertCode(t, res.Code, http.StatusOK)
+		if len(relations.Relationships(1)) != 1 {
+			t.Errorf("expected no new relationship")
+		}
+	})
+
+	t.Run("trashed contacts are hidden, purged ones drop their relationships", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/contacts/2", nil)
+		serve(req)
+		if strings.Contains(detail("1"), "Manager: ") || strings.Contains(detail("3"), "Assists them: ") {
+			t.Errorf("expected relationships with a trashed contact to be hidden")
+		}
+		req, _ = http.NewRequest(http.MethodDelete, "/contacts/2/purge", nil)
+		assertRedirect(t, serve(req), "/contacts/trash")
+		if len(relations.Relationships(1)) != 0 || len(relations.Relationships(3)) != 0 {
+			t.Errorf("expected relationships of the purged contact to be gone")
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package contactapp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+// how far ahead the dashboard looks for birthdays
+const birthdayWindow = 30
+
+// /dashboard lists what needs doing: overdue and upcoming reminders and the
+// birthdays of the next 30 days
+func (s *Server) getDashboard(w http.ResponseWriter, r *http.Request) {
+	today := models.DateOf(time.Now())
+	model := views.DashboardViewModel{
+		Today:       today,
+		CalendarURL: calendarURL(r, views.CurrentUser(r.Context())),
+	}
+	for _, reminder := range s.reminders.OpenReminders() {
+		contact, err := s.store.GetContact(reminder.ContactID)
+		if err != nil {
+			continue
+		}
+		due := views.DueReminder{Reminder: reminder, Contact: contact}
+		if reminder.Overdue(today) {
+			model.Overdue = append(model.Overdue, due)
+		} else {
+			model.Upcoming = append(model.Upcoming, due)
+		}
+	}
+	model.Birthdays = upcomingBirthdays(s.store.AllContacts(), today)
+	render(w, r.Context(), views.Dashboard(model))
+}
+
+// upcomingBirthdays within birthdayWindow days of today, soonest first
+func upcomingBirthdays(contacts []models.Contact, today time.Time) []views.UpcomingBirthday {
+	var birthdays []views.UpcomingBirthday
+	last := today.AddDate(0, 0, birthdayWindow)
+	for _, contact := range contacts {
+		if !contact.HasBirthday() {
+			continue
+		}
+		if next := contact.NextBirthday(today); !next.After(last) {
+			birthdays = append(birthdays, views.UpcomingBirthday{
+				Contact: contact,
+				Date:    next,
+				Age:     next.Year() - contact.Birthday.Year(),
+			})
+		}
+	}
+	slices.SortStableFunc(birthdays, func(a, b views.UpcomingBirthday) int {
+		return a.Date.Compare(b.Date)
+	})
+	return birthdays
+}
+
+// /contacts/{id}/reminders
+func (s *Server) addReminder(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	contact, err := s.store.GetContact(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	form := views.ReminderFormFromRequest(r)
+	if !form.Valid() {
+		s.renderDetail(w, r, contact, form)
+		return
+	}
+	reminder := form.ToReminder()
+	reminder.ContactID = id
+	if _, err := s.reminders.AddReminder(reminder); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	redirect(w, r, fmt.Sprintf("/contacts/%d", id))
+}
+
+func (s *Server) reminderFromPath(w http.ResponseWriter, r *http.Request) (models.Reminder, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return models.Reminder{}, false
+	}
+	reminder, err := s.reminders.GetReminder(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return models.Reminder{}, false
+	}
+	return reminder, true
+}
+
+// /reminders/{id}/done
+func (s *Server) completeReminder(w http.ResponseWriter, r *http.Request) {
+	reminder, ok := s.reminderFromPath(w, r)
+	if !ok {
+		return
+	}
+	reminder.Done = true
+	if err := s.reminders.EditReminder(reminder); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	renderString(w, "")
+}
+
+// /reminders/{id}
+func (s *Server) deleteReminder(w http.ResponseWriter, r *http.Request) {
+	reminder, ok := s.reminderFromPath(w, r)
+	if !ok {
+		return
+	}
+	if err := s.reminders.DeleteReminder(reminder.ID); err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	renderString(w, "")
+}
+
+// MarkDueReminders flags the open reminders that came due so they show up in
+// the notification badge
+func (s *Server) MarkDueReminders() {
+	today := models.DateOf(time.Now())
+	for _, reminder := range s.reminders.OpenReminders() {
+		if reminder.Notified || !reminder.DueBy(today) {
+			continue
+		}
+		// only the flag, the reminder may have been done since it was read
+		if err := s.reminders.MarkNotified(reminder.ID); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// RunReminderTicker marks due reminders every interval until ctx is done
+func (s *Server) RunReminderTicker(ctx context.Context, interval time.Duration) {
+	s.MarkDueReminders()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.MarkDueReminders()
+		}
+	}
+}
+
+// reminderBadge lets every page count the reminders that were marked due
+// and aren't done yet for its notification badge. only full pages show the
+// badge, partials and static files never count.
+func (s *Server) reminderBadge(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(views.WithDueReminders(r.Context(), s.countDueReminders)))
+	})
+}
+
+// countDueReminders leaves out reminders of contacts in the trash, as the
+// dashboard doesn't list them
+func (s *Server) countDueReminders() int {
+	count := 0
+	for _, reminder := range s.reminders.OpenReminders() {
+		if !reminder.Notified {
+			continue
+		}
+		if _, err := s.store.GetContact(reminder.ContactID); err == nil {
+			count++
+		}
+	}
+	return count
+}
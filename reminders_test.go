@@ -0,0 +1,182 @@
+package contactapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+func TestReminders(t *testing.T) {
+	store := NewinMemoryStore()
+	reminders := NewInMemoryReminderStore()
+	server := NewContactServer(store, WithReminderStore(reminders))
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	post := func(path string, f url.Values) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+	remind := func(id string, due time.Time, note string) *httptest.ResponseRecorder {
+		return post("/contacts/"+id+"/reminders", url.Values{
+			views.ReminderFormDue:  {due.Format(time.DateOnly)},
+			views.ReminderFormNote: {note},
+		})
+	}
+	dashboard := func() string {
+		return serve(newGetRequest("/dashboard")).Body.String()
+	}
+	today := models.DateOf(time.Now())
+
+	t.Run("reminders show on the contact and the dashboard", func(t *testing.T) {
+		assertRedirect(t, remind("1", today.AddDate(0, 0, -2), "send the contract"), "/contacts/1")
+		assertRedirect(t, remind("2", today.AddDate(0, 0, 5), "ask about the trip"), "/contacts/2")
+		if !strings.Contains(serve(newGetRequest("/contacts/1")).Body.String(), "send the contract") {
+			t.Errorf("expected the reminder on the detail page")
+		}
+		body := dashboard()
+		overdue, upcoming := strings.Index(body, "send the contract"), strings.Index(body, "ask about the trip")
+		if overdue == -1 || upcoming == -1 || overdue > strings.Index(body, "Upcoming") || upcoming < strings.Index(body, "Upcoming") {
+			t.Errorf("expected one overdue and one upcoming reminder, got %s", body)
+		}
+	})
+
+	t.Run("invalid reminders are rejected", func(t *testing.T) {
+		res := post("/contacts/1/reminders", url.Values{views.ReminderFormDue: {"tomorrow"}})
+		assertCode(t, res.Code, http.StatusOK)
+		if len(reminders.Reminders(1)) != 1 {
+			t.Errorf("expected no new reminder")
+		}
+	})
+
+	t.Run("the ticker puts due reminders in the badge", func(t *testing.T) {
+		if strings.Contains(dashboard(), `class="badge"`) {
+			t.Errorf("expected no badge before the ticker ran")
+		}
+		server.MarkDueReminders()
+		if !strings.Contains(dashboard(), `aria-label="1 reminders due"`) {
+			t.Errorf("expected a badge for the overdue reminder only")
+		}
+		id := reminders.Reminders(1)[0].ID
+		res := post("/reminders/"+strconv.Itoa(id)+"/done", nil)
+		assertCode(t, res.Code, http.StatusOK)
+		body := dashboard()
+		if strings.Contains(body, `class="badge"`) || strings.Contains(body, "send the contract") {
+			t.Errorf("expected done reminders to be gone")
+		}
+		// a ticker that read the reminder before it was done doesn't reopen it
+		reminders.MarkNotified(id)
+		if reminder, _ := reminders.GetReminder(id); !reminder.Done {
+			t.Errorf("marking a done reminder as notified reopened it")
+		}
+	})
+
+	t.Run("reminders of trashed contacts leave the badge", func(t *testing.T) {
+		remind("3", today.AddDate(0, 0, -1), "call back")
+		server.MarkDueReminders()
+		if !strings.Contains(dashboard(), `aria-label="1 reminders due"`) {
+			t.Errorf("expected a badge for the reminder of contact 3")
+		}
+		req, _ := http.NewRequest(http.MethodDelete, "/contacts/3", nil)
+		serve(req)
+		if strings.Contains(dashboard(), `class="badge"`) {
+			t.Errorf("expected no badge while contact 3 is in the trash")
+		}
+		post("/contacts/3/restore", nil)
+		reminders.DeleteContactReminders(3)
+	})
+
+	t.Run("follow-ups of interactions become reminders", func(t *testing.T) {
+		post("/contacts/3/interactions", url.Values{
+			views.InteractionFormType:     {"meeting"},
+			views.InteractionFormTime:     {time.Now().Add(-time.Hour).Format("2006-01-02T15:04")},
+			views.InteractionFormSummary:  {"lunch"},
+			views.InteractionFormFollowUp: {today.AddDate(0, 0, 7).Format(time.DateOnly)},
+		})
+		if r := reminders.Reminders(3); len(r) != 1 || r[0].Note != "Follow up: lunch" {
+			t.Errorf("expected a follow-up reminder, got %v", r)
+		}
+	})
+
+	t.Run("dashboard lists birthdays of the next 30 days", func(t *testing.T) {
+		soon, _ := store.GetContact(1)
+		soon.Birthday = today.AddDate(-40, 0, 10)
+		store.EditContact(soon)
+		later, _ := store.GetContact(2)
+		later.Birthday = today.AddDate(-30, 0, 45)
+		store.EditContact(later)
+		body := dashboard()
+		if !strings.Contains(body, "turns 40") || strings.Contains(body, "turns 30") {
+			t.Errorf("expected only Jack's birthday, got %s", body)
+		}
+	})
+
+	t.Run("deleting a reminder", func(t *testing.T) {
+		id := reminders.Reminders(2)[0].ID
+		req, _ := http.NewRequest(http.MethodDelete, "/reminders/"+strconv.Itoa(id), nil)
+		assertCode(t, serve(req).Code, http.StatusOK)
+		assertCode(t, serve(req).Code, http.StatusNotFound)
+	})
+}
+
+func TestNextBirthday(t *testing.T) {
+	leap := models.Contact{Birthday: time.Date(2000, 2, 29, 0, 0, 0, 0, time.UTC)}
+	cases := []struct {
+		contact models.Contact
+		day     time.Time
+		want    time.Time
+	}{
+		{leap, time.Date(2027, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{leap, time.Date(2028, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{leap, time.Date(2028, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2029, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{leap, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC), time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		if got := c.contact.NextBirthday(c.day); !got.Equal(c.want) {
+			t.Errorf("next birthday after %v: got %v, want %v", c.day, got, c.want)
+		}
+	}
+}
+
+// countingReminderStore counts the calls of OpenReminders
+type countingReminderStore struct {
+	*InMemoryReminderStore
+	calls int
+}
+
+func (s *countingReminderStore) OpenReminders() []models.Reminder {
+	s.calls++
+	return s.InMemoryReminderStore.OpenReminders()
+}
+
+func TestReminderBadgeOnFullPagesOnly(t *testing.T) {
+	reminders := &countingReminderStore{InMemoryReminderStore: NewInMemoryReminderStore()}
+	server := NewContactServer(NewinMemoryStore(), WithReminderStore(reminders))
+	session := signedIn(server)
+
+	for _, path := range []string{"/static/site.css", "/form-rows/phone", "/contacts/1/interactions"} {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(newGetRequest(path), session))
+		assertCode(t, res.Code, http.StatusOK)
+	}
+	if reminders.calls != 0 {
+		t.Errorf("counted due reminders %d times for partials and static files", reminders.calls)
+	}
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, withSession(newGetRequest("/contacts"), session))
+	if reminders.calls != 1 {
+		t.Errorf("counted due reminders %d times for a page, wanted once", reminders.calls)
+	}
+}
@@ -0,0 +1,128 @@
+package search
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+// DefaultMinDuplicateScore lets the same name through on its own, or a
+// similar one along with a shared email or phone number
+const DefaultMinDuplicateScore = 0.6
+
+// Duplicate is a pair of contacts that may be the same person
+type Duplicate struct {
+	A, B models.Contact
+	// from 0 to 1
+	Score float64
+	// what the contacts have in common, e.g. "same phone"
+	Reasons []string
+}
+
+// FindDuplicates scores every pair of contacts by how alike their names,
+// emails and phone numbers are and returns the pairs scoring at least
+// minScore, best first. A is always the older contact of a pair.
+func FindDuplicates(contacts []models.Contact, minScore float64) []Duplicate {
+	var duplicates []Duplicate
+	for i, x := range contacts {
+		for _, y := range contacts[i+1:] {
+			a, b := x, y
+			if b.ID < a.ID {
+				a, b = b, a
+			}
+			if d := compareContacts(a, b); d.Score >= minScore {
+				duplicates = append(duplicates, d)
+			}
+		}
+	}
+	slices.SortStableFunc(duplicates, func(x, y Duplicate) int {
+		if c := cmp.Compare(y.Score, x.Score); c != 0 {
+			return c
+		}
+		return cmp.Or(cmp.Compare(x.A.ID, y.A.ID), cmp.Compare(x.B.ID, y.B.ID))
+	})
+	return duplicates
+}
+
+// compareContacts weighs names the most, as people change emails and
+// phones. a shared email or phone only counts once.
+func compareContacts(a, b models.Contact) Duplicate {
+	d := Duplicate{A: a, B: b}
+	name := nameSimilarity(a, b)
+	switch {
+	case name == 1:
+		d.Reasons = append(d.Reasons, "same name")
+	case name > 0:
+		d.Reasons = append(d.Reasons, "similar name")
+	}
+	email := emailSimilarity(a, b)
+	switch {
+	case email == 1:
+		d.Reasons = append(d.Reasons, "same email")
+	case email > 0:
+		d.Reasons = append(d.Reasons, "similar email")
+	}
+	phone := 0.0
+	if sharePhone(a, b) {
+		phone = 1
+		d.Reasons = append(d.Reasons, "same phone")
+	}
+	d.Score = 0.6*name + 0.4*max(email, phone)
+	return d
+}
+
+// nameSimilarity compares the full names, either way around, and counts
+// names that sound the same as one typo away. 0 when they aren't alike.
+func nameSimilarity(a, b models.Contact) float64 {
+	af, al := models.Fold(a.FirstName), models.Fold(a.LastName)
+	bf, bl := models.Fold(b.FirstName), models.Fold(b.LastName)
+	if af+al == "" || bf+bl == "" {
+		return 0
+	}
+	score := max(
+		similarity(af+" "+al, bf+" "+bl),
+		similarity(af+" "+al, bl+" "+bf),
+	)
+	if sf, sl := soundex(af), soundex(al); sf != "" && sl != "" && sf == soundex(bf) && sl == soundex(bl) {
+		score = max(score, DefaultMinSimilarity)
+	}
+	if score < DefaultMinSimilarity {
+		return 0
+	}
+	return score
+}
+
+// emailSimilarity is 1 for a shared address and less for the same name at
+// another domain, e.g. jack@home.com and jack@work.com
+func emailSimilarity(a, b models.Contact) float64 {
+	score := 0.0
+	for _, x := range a.Emails {
+		for _, y := range b.Emails {
+			xl, xd, _ := strings.Cut(models.Fold(x.Address), "@")
+			yl, yd, _ := strings.Cut(models.Fold(y.Address), "@")
+			switch {
+			case xl == "" || yl == "":
+			case xl == yl && xd == yd:
+				return 1
+			case xl == yl:
+				score = 0.7
+			}
+		}
+	}
+	return score
+}
+
+// sharePhone reports whether a and b have a number in common, see
+// models.SamePhone
+func sharePhone(a, b models.Contact) bool {
+	for _, x := range a.Phones {
+		for _, y := range b.Phones {
+			if models.SamePhone(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
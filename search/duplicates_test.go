@@ -0,0 +1,37 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	contacts := []models.Contact{
+		{ID: 1, FirstName: "Jack", LastName: "Jackson", Emails: []models.Email{{Address: "jack@home.com"}}},
+		{ID: 2, FirstName: "John", LastName: "Doe", Phones: []models.Phone{{Number: "+1 (555) 123-4567"}}},
+		{ID: 3, FirstName: "Chloé", LastName: "Martin"},
+		{ID: 4, FirstName: "jack", LastName: "jakson", Emails: []models.Email{{Address: "JACK@work.com"}}},
+		{ID: 5, FirstName: "Jon", LastName: "Doe", Phones: []models.Phone{{Number: "555 1234567"}}},
+		{ID: 6, FirstName: "Martin", LastName: "Chloe"},
+		{ID: 7, FirstName: "Arthur", LastName: "Morgan", Phones: []models.Phone{{Number: "+1 (555) 123-4567"}}},
+	}
+	type pair struct {
+		a, b    int
+		reasons []string
+	}
+	var got []pair
+	for _, d := range FindDuplicates(contacts, DefaultMinDuplicateScore) {
+		got = append(got, pair{d.A.ID, d.B.ID, d.Reasons})
+	}
+	want := []pair{
+		{2, 5, []string{"similar name", "same phone"}},
+		{1, 4, []string{"similar name", "similar email"}},
+		// the name swapped around, with nothing else to go on
+		{3, 6, []string{"same name"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
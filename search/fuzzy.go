@@ -0,0 +1,147 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+// DefaultMinSimilarity lets one typo through in a four letter word and two in
+// a seven letter one
+const DefaultMinSimilarity = 0.7
+
+// editDistance counts the insertions, deletions, substitutions and swaps of
+// neighbouring letters between a and b (optimal string alignment)
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(t)]
+}
+
+// similarity is 1 for equal words down to 0 for nothing in common
+func similarity(a, b string) float64 {
+	longest := max(len([]rune(a)), len([]rune(b)))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(a, b))/float64(longest)
+}
+
+// soundex codes how a name sounds in English, e.g. "morgan" and "morgen"
+// both are M625. empty for words that don't start with a letter a-z.
+func soundex(word string) string {
+	codes := map[rune]byte{
+		'b': '1', 'f': '1', 'p': '1', 'v': '1',
+		'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+		'd': '3', 't': '3',
+		'l': '4',
+		'm': '5', 'n': '5',
+		'r': '6',
+	}
+	if word == "" || word[0] < 'a' || word[0] > 'z' {
+		return ""
+	}
+	code := []byte{byte(unicode.ToUpper(rune(word[0])))}
+	last := codes[rune(word[0])]
+	for _, r := range word[1:] {
+		c, ok := codes[r]
+		switch {
+		case ok && c != last:
+			code = append(code, c)
+			last = c
+		case !ok && r != 'h' && r != 'w':
+			// vowels separate letters with the same code, h and w don't
+			last = 0
+		}
+		if len(code) == 4 {
+			break
+		}
+	}
+	return string(code) + strings.Repeat("0", 4-len(code))
+}
+
+// Suggest corrects the misspelled words of a query that finds nothing, e.g.
+// "jonh" to "john". a word is replaced by the most similar indexed word,
+// names that sound the same count as similar enough. returns an empty string
+// when there is nothing better to search for.
+func (i *Index) Suggest(query string) string {
+	terms := models.QueryTerms(query)
+	if len(terms) == 0 {
+		return ""
+	}
+	i.mu.Lock()
+	corrected := make([]string, len(terms))
+	changed := false
+	for n, term := range terms {
+		if len(i.expand(term)) > 0 {
+			corrected[n] = term
+			continue
+		}
+		corrected[n] = i.closest(term)
+		if corrected[n] == "" {
+			i.mu.Unlock()
+			return ""
+		}
+		changed = true
+	}
+	i.mu.Unlock()
+	suggestion := strings.Join(corrected, " ")
+	if !changed || len(i.Search(suggestion)) == 0 {
+		return ""
+	}
+	return suggestion
+}
+
+// closest is the indexed word most like term, preferring names that sound
+// like it and then the more common words. numbers aren't corrected. expects
+// the lock to be held.
+func (i *Index) closest(term string) string {
+	if isNumber(term) {
+		return ""
+	}
+	sound := soundex(term)
+	var (
+		best      string
+		bestScore float64
+	)
+	for candidate, ids := range i.postings {
+		score := similarity(term, candidate)
+		if _, ok := i.names[candidate]; ok && sound != "" && soundex(candidate) == sound {
+			// a name that sounds the same is as good as one typo away
+			score = max(score, i.MinSimilarity)
+		}
+		if score < i.MinSimilarity {
+			continue
+		}
+		// break ties by how common the word is, then alphabetically
+		score += float64(len(ids)) / float64(len(i.docs)+1) / 1000
+		if score > bestScore || score == bestScore && candidate < best {
+			best, bestScore = candidate, score
+		}
+	}
+	return best
+}
+
+func isNumber(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) }) == -1
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestEditDistance(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want int
+	}{
+		{"john", "john", 0},
+		{"jonh", "john", 1},
+		{"morgen", "morgan", 1},
+		{"jak", "jack", 1},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+	} {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("distance between %q and %q: got %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestSoundex(t *testing.T) {
+	for word, want := range map[string]string{
+		"robert":   "R163",
+		"rupert":   "R163",
+		"ashcraft": "A261",
+		"tymczak":  "T522",
+		"pfister":  "P236",
+		"lee":      "L000",
+		"42":       "",
+	} {
+		if got := soundex(word); got != want {
+			t.Errorf("soundex of %q: got %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	index := NewIndex()
+	index.Add(models.Contact{ID: 1, FirstName: "John", LastName: "Doe"})
+	index.Add(models.Contact{ID: 2, FirstName: "Arthur", LastName: "Morgan", Company: "Rockstar"})
+	index.Add(models.Contact{ID: 3, FirstName: "Robert", LastName: "Smith"})
+
+	for query, want := range map[string]string{
+		"Jonh":          "john",
+		"Morgen":        "morgan",
+		"arthur morgen": "arthur morgan",
+		"rupert":        "robert", // too far apart for a typo, but sounds the same
+		"smyth":         "smith",
+		"john":          "",
+		"xyzzy":         "",
+		"jonh morgen":   "",
+		"12345":         "",
+	} {
+		if got := index.Suggest(query); got != want {
+			t.Errorf("suggestion for %q: got %q, want %q", query, got, want)
+		}
+	}
+
+	index.MinSimilarity = 0.9
+	if got := index.Suggest("rokstar"); got != "" {
+		t.Errorf("expected a stricter threshold to reject %q, got %q", "rokstar", got)
+	}
+}
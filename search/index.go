@@ -0,0 +1,230 @@
+// Package search keeps an inverted index of contacts and ranks matches with
+// BM25F: BM25 over several fields, each weighted by how much a match in it
+// says about the contact.
+package search
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+// Boosts weigh the fields of models.ContactSearchFields, a name match beats
+// a note match
+var Boosts = map[string]float64{
+	models.SearchName:    3,
+	models.SearchEmail:   2,
+	models.SearchPhone:   2,
+	models.SearchCompany: 1.5,
+	models.SearchNotes:   1,
+	models.SearchCustom:  1,
+}
+
+// BM25 parameters, the usual defaults
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// how much a word matching only by its start counts, before taking off for
+// the missing part. rarer longer words would outrank exact matches otherwise.
+const prefixWeight = 0.5
+
+// Result is a matching contact, best first
+type Result struct {
+	ID    int
+	Score float64
+}
+
+type document struct {
+	// token counts and lengths by field
+	terms   map[string]map[string]int
+	lengths map[string]int
+}
+
+// Index is safe for concurrent use
+type Index struct {
+	mu   sync.RWMutex
+	docs map[int]document
+	// contact ids by term
+	postings map[string]map[int]struct{}
+	// total tokens by field, for average field lengths
+	lengths map[string]int
+	// sorted terms for prefix lookups, nil when stale
+	vocabulary []string
+	// how many names each name term is in, for phonetic suggestions
+	names map[string]int
+	// how close a word has to be to a misspelled one to be suggested for it,
+	// from 0 to 1
+	MinSimilarity float64
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[int]document),
+		postings: make(map[string]map[int]struct{}),
+		lengths:  make(map[string]int),
+		names:    make(map[string]int),
+
+		MinSimilarity: DefaultMinSimilarity,
+	}
+}
+
+// Add indexes a contact, replacing what was indexed for it before
+func (i *Index) Add(c models.Contact) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(c.ID)
+	doc := document{terms: make(map[string]map[string]int), lengths: make(map[string]int)}
+	for _, field := range models.ContactSearchFields(c) {
+		counts := make(map[string]int)
+		for _, token := range field.Tokens {
+			counts[token]++
+			if i.postings[token] == nil {
+				i.postings[token] = make(map[int]struct{})
+				i.vocabulary = nil
+			}
+			i.postings[token][c.ID] = struct{}{}
+		}
+		if field.Name == models.SearchName {
+			for token := range counts {
+				i.names[token]++
+			}
+		}
+		doc.terms[field.Name] = counts
+		doc.lengths[field.Name] = len(field.Tokens)
+		i.lengths[field.Name] += len(field.Tokens)
+	}
+	i.docs[c.ID] = doc
+}
+
+// Remove drops a contact from the index
+func (i *Index) Remove(id int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.remove(id)
+}
+
+func (i *Index) remove(id int) {
+	doc, ok := i.docs[id]
+	if !ok {
+		return
+	}
+	for field, counts := range doc.terms {
+		for token := range counts {
+			delete(i.postings[token], id)
+			if len(i.postings[token]) == 0 {
+				delete(i.postings, token)
+				i.vocabulary = nil
+			}
+		}
+		i.lengths[field] -= doc.lengths[field]
+		if field == models.SearchName {
+			for token := range counts {
+				if i.names[token]--; i.names[token] == 0 {
+					delete(i.names, token)
+				}
+			}
+		}
+	}
+	delete(i.docs, id)
+}
+
+// expand lists the indexed terms starting with prefix. expects the lock to
+// be held for writing.
+func (i *Index) expand(prefix string) []string {
+	if i.vocabulary == nil {
+		i.vocabulary = make([]string, 0, len(i.postings))
+		for term := range i.postings {
+			i.vocabulary = append(i.vocabulary, term)
+		}
+		slices.Sort(i.vocabulary)
+	}
+	start := sort.SearchStrings(i.vocabulary, prefix)
+	end := start
+	for end < len(i.vocabulary) && strings.HasPrefix(i.vocabulary[end], prefix) {
+		end++
+	}
+	return i.vocabulary[start:end]
+}
+
+// Search finds the contacts matching every term of query, see
+// models.MatchesQuery, ordered by relevance. a term matching only the start
+// of a word counts for less, the more of the word is missing the less.
+func (i *Index) Search(query string) []Result {
+	terms := models.QueryTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	// expanding may sort the vocabulary
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	scores := make(map[int]float64)
+	for n, term := range terms {
+		best := make(map[int]float64)
+		for _, expanded := range i.expand(term) {
+			weight := 1.0
+			if expanded != term {
+				weight = prefixWeight * float64(len(term)) / float64(len(expanded))
+			}
+			for id := range i.postings[expanded] {
+				if n > 0 {
+					if _, ok := scores[id]; !ok {
+						continue
+					}
+				}
+				best[id] = max(best[id], weight*i.score(id, expanded))
+			}
+		}
+		for id := range scores {
+			if _, ok := best[id]; !ok {
+				delete(scores, id)
+			}
+		}
+		for id, score := range best {
+			scores[id] += score
+		}
+		if len(scores) == 0 {
+			return nil
+		}
+	}
+	results := make([]Result, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, Result{ID: id, Score: score})
+	}
+	slices.SortFunc(results, func(a, b Result) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return a.ID - b.ID
+	})
+	return results
+}
+
+// score is the BM25F score of term for contact id
+func (i *Index) score(id int, term string) float64 {
+	doc := i.docs[id]
+	var tf float64
+	for field, counts := range doc.terms {
+		count := counts[term]
+		if count == 0 {
+			continue
+		}
+		avg := float64(i.lengths[field]) / float64(len(i.docs))
+		norm := 1 - b
+		if avg > 0 {
+			norm += b * float64(doc.lengths[field]) / avg
+		}
+		tf += Boosts[field] * float64(count) / norm
+	}
+	n, df := float64(len(i.docs)), float64(len(i.postings[term]))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+	return idf * tf * (k1 + 1) / (tf + k1)
+}
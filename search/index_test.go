@@ -0,0 +1,64 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func ids(results []Result) []int {
+	var ids []int
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestIndex(t *testing.T) {
+	index := NewIndex()
+	index.Add(models.Contact{ID: 1, FirstName: "Mark", LastName: "Twain", Notes: "writer"})
+	index.Add(models.Contact{ID: 2, FirstName: "Anna", LastName: "Berg", Notes: "met Mark at the market"})
+	index.Add(models.Contact{ID: 3, FirstName: "Markus", LastName: "Weber", Company: "Writers Guild"})
+	index.Add(models.Contact{ID: 4, FirstName: "Léa", LastName: "Roux", Phones: []models.Phone{{Number: "+33 6 12 34"}}})
+
+	cases := []struct {
+		query string
+		want  []int
+	}{
+		// name beats notes, exact beats prefix
+		{"mark", []int{1, 3, 2}},
+		{"writ", []int{1, 3}},
+		{"mark writer", []int{1, 3}},
+		{"LEA", []int{4}},
+		{"6 12", []int{4}},
+		{"nobody", nil},
+		{"", nil},
+	}
+	for _, c := range cases {
+		got := ids(index.Search(c.query))
+		if len(got) != len(c.want) {
+			t.Errorf("searching %q got %v, wanted %v", c.query, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("searching %q got %v, wanted %v", c.query, got, c.want)
+				break
+			}
+		}
+	}
+
+	t.Run("edits and removals update the index", func(t *testing.T) {
+		index.Add(models.Contact{ID: 1, FirstName: "Samuel", LastName: "Clemens"})
+		index.Remove(3)
+		if got := ids(index.Search("mark")); len(got) != 1 || got[0] != 2 {
+			t.Errorf("got %v, wanted only contact 2", got)
+		}
+		if got := ids(index.Search("samuel")); len(got) != 1 || got[0] != 1 {
+			t.Errorf("got %v, wanted the edited contact", got)
+		}
+		if got := index.Search("twain"); len(got) != 0 {
+			t.Errorf("expected the old name to be gone, got %v", got)
+		}
+	})
+}
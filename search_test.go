@@ -0,0 +1,181 @@
+package contactapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDidYouMean(t *testing.T) {
+	server := NewContactServer(NewinMemoryStore())
+	session := signedIn(server)
+	find := func(q string, active bool) string {
+		req := newGetRequestWithQuery("/contacts", q)
+		if active {
+			req.Header.Set("HX-Trigger", "search")
+		}
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		assertCode(t, res.Code, http.StatusOK)
+		return res.Body.String()
+	}
+
+	t.Run("misspelled names fall back to the corrected query", func(t *testing.T) {
+		for _, active := range []bool{false, true} {
+			body := find("Morgen", active)
+			if !strings.Contains(body, "Did you mean") || !strings.Contains(body, `href="/contacts?q=morgan"`) {
+				t.Errorf("expected a suggestion, got %s", body)
+			}
+			if !strings.Contains(body, "<mark>Morgan</mark>") {
+				t.Errorf("expected Arthur Morgan with the corrected word highlighted")
+			}
+		}
+	})
+
+	t.Run("no suggestion when the query finds something", func(t *testing.T) {
+		if body := find("jack", true); strings.Contains(body, "Did you mean") || !strings.Contains(body, "<mark>Jack</mark>") {
+			t.Errorf("expected plain results, got %s", body)
+		}
+	})
+
+	t.Run("no suggestion for gibberish", func(t *testing.T) {
+		if body := find("qqqqqq", true); strings.Contains(body, "Did you mean") {
+			t.Errorf("expected no suggestion, got %s", body)
+		}
+	})
+}
+
+func TestQueryLanguage(t *testing.T) {
+	store := NewinMemoryStore()
+	jack, _ := store.GetContact(1)
+	jack.Tags = []string{"customer"}
+	jack.Company = "Acme"
+	store.EditContact(jack)
+	john, _ := store.GetContact(2)
+	john.Tags = []string{"customer"}
+	store.EditContact(john)
+	server := NewContactServer(store)
+	session := signedIn(server)
+	find := func(q string) string {
+		req := newGetRequest("/contacts?q=" + url.QueryEscape(q))
+		req.Header.Set("HX-Trigger", "search")
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res.Body.String()
+	}
+
+	t.Run("structured queries filter by field", func(t *testing.T) {
+		body := find("tag:customer -company:acme")
+		if strings.Contains(body, "Jackson") || !strings.Contains(body, "Doe") || strings.Contains(body, "Morgan") {
+			t.Errorf("expected only John, got %s", body)
+		}
+		body = find("email:*@morgan.com OR jack")
+		if !strings.Contains(body, "Jackson") || strings.Contains(body, "Doe") || !strings.Contains(body, "Morgan") {
+			t.Errorf("expected Jack and Arthur, got %s", body)
+		}
+	})
+
+	t.Run("parse errors show next to the search box", func(t *testing.T) {
+		body := find("tag:customer (jack")
+		if !strings.Contains(body, `id="search-error"`) || !strings.Contains(body, "missing closing parenthesis at column 14") {
+			t.Errorf("expected the parse error, got %s", body)
+		}
+		if !strings.Contains(body, `hx-swap-oob="true"`) || strings.Contains(body, "Jackson") {
+			t.Errorf("expected an out of band error and no results")
+		}
+	})
+}
+
+func TestSortableColumns(t *testing.T) {
+	server := NewContactServer(NewinMemoryStore())
+	session := signedIn(server)
+	get := func(path string, active bool) string {
+		req := newGetRequest(path)
+		if active {
+			req.Header.Set("HX-Trigger", "search")
+		}
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		assertCode(t, res.Code, http.StatusOK)
+		return res.Body.String()
+	}
+
+	t.Run("headers sort ascending first and keep the search and page", func(t *testing.T) {
+		body := get("/contacts?q=j&page=1", false)
+		if !strings.Contains(body, `href="/contacts?order=asc&amp;page=1&amp;q=j&amp;sort=last"`) {
+			t.Errorf("expected a link sorting by last name, got %s", body)
+		}
+	})
+
+	t.Run("clicking the sorted column reverses it", func(t *testing.T) {
+		body := get("/contacts?sort=last&order=asc", false)
+		if !strings.Contains(body, `<th aria-sort="ascending"><a href="/contacts?order=desc&amp;sort=last"`) {
+			t.Errorf("expected the last name header to reverse the order, got %s", body)
+		}
+		if strings.Index(body, "Doe") > strings.Index(body, "Morgan") {
+			t.Errorf("expected Doe before Morgan")
+		}
+		body = get("/contacts?sort=last&order=desc", false)
+		if strings.Index(body, "Doe") < strings.Index(body, "Morgan") {
+			t.Errorf("expected Morgan before Doe")
+		}
+	})
+
+	t.Run("active search keeps the sort and updates the headers", func(t *testing.T) {
+		body := get("/contacts?q=j&sort=first&order=desc", true)
+		if !strings.Contains(body, `<thead id="contacts-head" hx-swap-oob="true">`) ||
+			!strings.Contains(body, `href="/contacts?order=asc&amp;q=j&amp;sort=first"`) {
+			t.Errorf("expected the headers out of band, got %s", body)
+		}
+		if strings.Index(body, "John") > strings.Index(body, "Jack") {
+			t.Errorf("expected John before Jack")
+		}
+	})
+}
+
+func TestPhoneNumbers(t *testing.T) {
+	server := NewContactServer(NewinMemoryStore())
+	session := signedIn(server)
+	serve := func(req *http.Request) string {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res.Body.String()
+	}
+
+	t.Run("shown nationally or internationally", func(t *testing.T) {
+		body := serve(newGetRequest("/contacts"))
+		if !strings.Contains(body, "(415) 555-0132") || !strings.Contains(body, "+44 7400 123456") {
+			t.Errorf("expected formatted numbers, got %s", body)
+		}
+	})
+
+	t.Run("search matches however the number is written", func(t *testing.T) {
+		for q, want := range map[string]string{
+			"4155550132":      "Jack",
+			"+1 415 555 0132": "Jack",
+			"(415) 555-0132":  "Jack",
+			"07400 123456":    "Arthur",
+			"phone:+1415555":  "Jack",
+		} {
+			body := serve(newGetRequestWithQuery("/contacts", url.QueryEscape(q)))
+			if !strings.Contains(body, want) {
+				t.Errorf("expected %q to find %s", q, want)
+			}
+		}
+	})
+
+	t.Run("numbers that don't parse are rejected", func(t *testing.T) {
+		f := url.Values{}
+		f.Set("first_name", "Ada")
+		f.Set("last_name", "L")
+		f.Set("email", "ada@l.com")
+		f.Set("phone", "213214")
+		req, _ := http.NewRequest(http.MethodPost, "/contacts/new", strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if body := serve(req); !strings.Contains(body, "not a valid phone number") {
+			t.Errorf("expected a phone error, got %s", body)
+		}
+	})
+}
@@ -4,44 +4,190 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/a-h/templ"
 	"github.com/rezbow/contact-app/archiver"
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/blob"
+	"github.com/rezbow/contact-app/export"
 	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/oidc"
+	"github.com/rezbow/contact-app/query"
 	"github.com/rezbow/contact-app/views"
 )
 
 var (
 	ErrDuplicateEmail = errors.New("email is taken")
 	ErrNotFound       = errors.New("contact not found")
+	ErrEditConflict   = errors.New("contact was changed by someone else")
 )
 
 const (
-	staticFilesDir = http.Dir("./static/")
+	staticFilesDir     = http.Dir("./static/")
+	defaultPhoneRegion = "US"
 )
 
 type ContactStore interface {
-	GetContacts(page int) ([]models.Contact, int)
-	FilterContacts(string, int) ([]models.Contact, int)
-	AddContact(models.Contact) error
+	GetContacts(models.Page) models.ContactPage
+	// every contact not in the trash, used for exports
+	AllContacts() []models.Contact
+	// FilterContacts pages through the contacts filter.Matches, ordered by
+	// filter.Sort
+	FilterContacts(models.ContactFilter, models.Page) models.ContactPage
+	// SuggestQuery corrects the typos of a query that finds nothing, empty
+	// when it has no better query
+	SuggestQuery(query string) string
+	AddContact(models.Contact) (models.Contact, error)
 	GetContact(int) (models.Contact, error)
+	// EditContact replaces a contact, except for CreatedAt, UpdatedAt and
+	// Version which it sets, and for LastContacted which only
+	// SetLastContacted changes. it fails with ErrEditConflict unless the
+	// contact has the Version of the one it replaces.
 	EditContact(models.Contact) error
+	SetLastContacted(id int, t time.Time) error
+	// DeleteContact moves a contact to the trash
 	DeleteContact(int) error
+	GetTrash() []models.Contact
+	// EditTrashedContact is EditContact for a contact in the trash, which
+	// stays there
+	EditTrashedContact(models.Contact) error
+	RestoreContact(int) error
+	PurgeContact(int) error
+	PurgeDeletedBefore(time.Time) []models.Contact
 	DuplicateEmail(email string, contactId int) bool
 	Count() int
 }
 
+type UserStore interface {
+	GetUser(id int) (models.User, error)
+	GetUserByEmail(email string) (models.User, error)
+	GetUserBySubject(issuer, subject string) (models.User, error)
+	GetUserByCalendarToken(token string) (models.User, error)
+	AddUser(models.User) (models.User, error)
+	EditUser(models.User) error
+}
+
+type RevisionStore interface {
+	AddRevision(models.Revision) (models.Revision, error)
+	Revisions(contactID int) []models.Revision
+	Revision(contactID, number int) (models.Revision, error)
+	MoveContactRevisions(from, to int)
+}
+
+// FieldSchema holds the custom fields contacts of the address book can have
+type FieldSchema interface {
+	Fields() []models.FieldDefinition
+	SaveField(models.FieldDefinition) error
+	DeleteField(key string) error
+}
+
+type SmartListStore interface {
+	SmartLists() []models.SmartList
+	GetSmartList(id int) (models.SmartList, error)
+	AddSmartList(models.SmartList) (models.SmartList, error)
+	DeleteSmartList(id int) error
+}
+
+type GroupStore interface {
+	Groups() []models.Group
+	GetGroup(id int) (models.Group, error)
+	AddGroup(models.Group) (models.Group, error)
+	EditGroup(models.Group) error
+	DeleteGroup(id int) error
+	RemoveContactFromGroups(contactID int)
+}
+
+type RelationshipStore interface {
+	Relationships(contactID int) []models.Relationship
+	AddRelationship(models.Relationship) (models.Relationship, error)
+	DeleteRelationship(id int) error
+	DeleteContactRelationships(contactID int)
+	MoveContactRelationships(from, to int)
+}
+
+type InteractionStore interface {
+	// newest first
+	Interactions(contactID int) []models.Interaction
+	AddInteraction(models.Interaction) (models.Interaction, error)
+	DeleteInteraction(id int) error
+	DeleteContactInteractions(contactID int)
+	MoveContactInteractions(from, to int)
+}
+
+type ReminderStore interface {
+	// by due date
+	Reminders(contactID int) []models.Reminder
+	// reminders of every contact that aren't done, by due date
+	OpenReminders() []models.Reminder
+	GetReminder(id int) (models.Reminder, error)
+	AddReminder(models.Reminder) (models.Reminder, error)
+	EditReminder(models.Reminder) error
+	// MarkNotified flags a reminder as shown in the badge, leaving the rest
+	// of it as it is
+	MarkNotified(id int) error
+	DeleteReminder(id int) error
+	DeleteContactReminders(contactID int)
+	MoveContactReminders(from, to int)
+}
+
+// BlobStore keeps binary data like photos, see package blob
+type BlobStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+type AuditLog interface {
+	Record(audit.Event) error
+	Events(audit.Filter) []audit.Event
+}
+
 type Server struct {
-	store ContactStore
+	store        ContactStore
+	users        UserStore
+	sessions     *SessionStore
+	oidc         *oidc.Provider
+	audit        AuditLog
+	revisions    RevisionStore
+	fields       FieldSchema
+	groups       GroupStore
+	blobs        BlobStore
+	relations    RelationshipStore
+	interactions InteractionStore
+	reminders    ReminderStore
+	lists        SmartListStore
+	// how long deleted contacts stay in the trash
+	trashRetention time.Duration
+	// region of phone numbers without a country code
+	phoneRegion string
 	http.Handler
 }
 
-func NewContactServer(store ContactStore) *Server {
+func NewContactServer(store ContactStore, options ...Option) *Server {
 	server := &Server{
-		store: store,
+		store:          store,
+		users:          NewInMemoryUserStore(),
+		sessions:       NewSessionStore(),
+		audit:          audit.New(io.Discard),
+		revisions:      NewInMemoryRevisionStore(),
+		fields:         NewInMemoryFieldSchema(),
+		groups:         NewInMemoryGroupStore(),
+		blobs:          blob.NewMemory(),
+		relations:      NewInMemoryRelationshipStore(),
+		interactions:   NewInMemoryInteractionStore(),
+		reminders:      NewInMemoryReminderStore(),
+		lists:          NewInMemorySmartListStore(),
+		trashRetention: defaultTrashRetention,
+		phoneRegion:    defaultPhoneRegion,
+	}
+	for _, option := range options {
+		option(server)
 	}
 	router := http.NewServeMux()
 	router.Handle("GET /contacts", http.HandlerFunc(server.getContacts))
@@ -60,7 +206,63 @@ func NewContactServer(store ContactStore) *Server {
 	router.Handle("GET /contacts/archive", http.HandlerFunc(server.archiveStatus))
 	router.Handle("GET /contacts/archive/file", http.HandlerFunc(server.archiveDownload))
 
-	server.Handler = router
+	router.Handle("GET /login", server.startSession(http.HandlerFunc(server.loginPage)))
+	router.Handle("POST /login", http.HandlerFunc(server.login))
+	router.Handle("POST /logout", http.HandlerFunc(server.logout))
+	router.Handle("GET /auth/oidc/login", server.startSession(http.HandlerFunc(server.oidcLogin)))
+	router.Handle("GET /auth/oidc/callback", http.HandlerFunc(server.oidcCallback))
+
+	router.Handle("GET /contacts/{id}/history", http.HandlerFunc(server.getContactHistory))
+	router.Handle("POST /contacts/{id}/history/{rev}/revert", http.HandlerFunc(server.revertContact))
+	router.Handle("GET /form-rows/{kind}", http.HandlerFunc(server.getFormRow))
+	router.Handle("GET /contacts/trash", http.HandlerFunc(server.getTrash))
+	router.Handle("POST /contacts/restore", http.HandlerFunc(server.restoreBulkContact))
+	router.Handle("POST /contacts/{id}/restore", http.HandlerFunc(server.restoreContact))
+	router.Handle("DELETE /contacts/{id}/purge", http.HandlerFunc(server.purgeContact))
+	router.Handle("GET /audit", http.HandlerFunc(server.getAuditLog))
+	router.Handle("GET /fields", http.HandlerFunc(server.getFields))
+	router.Handle("POST /fields", http.HandlerFunc(server.saveField))
+	router.Handle("DELETE /fields/{key}", http.HandlerFunc(server.deleteField))
+	router.Handle("GET /contacts/export", http.HandlerFunc(server.exportContacts))
+	router.Handle("GET /tags", http.HandlerFunc(server.getTags))
+	router.Handle("GET /tags/suggest", http.HandlerFunc(server.suggestTags))
+	router.Handle("POST /tags/{name}/rename", http.HandlerFunc(server.renameTag))
+	router.Handle("DELETE /tags/{name}", http.HandlerFunc(server.deleteTag))
+	router.Handle("GET /groups", http.HandlerFunc(server.getGroups))
+	router.Handle("POST /groups", http.HandlerFunc(server.newGroup))
+	router.Handle("POST /groups/members", http.HandlerFunc(server.addSelectedToGroup))
+	router.Handle("GET /groups/{id}", http.HandlerFunc(server.getGroupDetail))
+	router.Handle("POST /groups/{id}/edit", http.HandlerFunc(server.editGroup))
+	router.Handle("DELETE /groups/{id}", http.HandlerFunc(server.deleteGroup))
+	router.Handle("DELETE /groups/{id}/members/{contact}", http.HandlerFunc(server.removeGroupMember))
+	router.Handle("POST /groups/{id}/members/{contact}/move", http.HandlerFunc(server.moveGroupMember))
+	router.Handle("GET /groups/{id}/export", http.HandlerFunc(server.exportGroup))
+	router.Handle("POST /contacts/{id}/photo", http.HandlerFunc(server.uploadPhoto))
+	router.Handle("DELETE /contacts/{id}/photo", http.HandlerFunc(server.deletePhoto))
+	router.Handle("GET /photos/{key}", http.HandlerFunc(server.getPhoto))
+	router.Handle("GET /contacts/{id}/vcard", http.HandlerFunc(server.getVCard))
+	router.Handle("GET /contacts/{id}/relationships/search", http.HandlerFunc(server.searchRelated))
+	router.Handle("POST /contacts/{id}/relationships", http.HandlerFunc(server.addRelationship))
+	router.Handle("DELETE /contacts/{id}/relationships/{rel}", http.HandlerFunc(server.deleteRelationship))
+	router.Handle("GET /contacts/{id}/interactions", http.HandlerFunc(server.getInteractions))
+	router.Handle("POST /contacts/{id}/interactions", http.HandlerFunc(server.addInteraction))
+	router.Handle("DELETE /contacts/{id}/interactions/{interaction}", http.HandlerFunc(server.deleteInteraction))
+	router.Handle("GET /dashboard", http.HandlerFunc(server.getDashboard))
+	router.Handle("POST /contacts/{id}/reminders", http.HandlerFunc(server.addReminder))
+	router.Handle("POST /reminders/{id}/done", http.HandlerFunc(server.completeReminder))
+	router.Handle("DELETE /reminders/{id}", http.HandlerFunc(server.deleteReminder))
+	router.Handle("POST /calendar/token", http.HandlerFunc(server.resetCalendarToken))
+	router.Handle("GET /calendar/{file}", http.HandlerFunc(server.getCalendar))
+	router.Handle("GET /lists", http.HandlerFunc(server.getSmartLists))
+	router.Handle("POST /lists", http.HandlerFunc(server.newSmartList))
+	router.Handle("DELETE /lists/{id}", http.HandlerFunc(server.deleteSmartList))
+	router.Handle("GET /contacts/duplicates", http.HandlerFunc(server.getDuplicates))
+	router.Handle("GET /contacts/merge", http.HandlerFunc(server.mergeContactsPage))
+	router.Handle("POST /contacts/merge", http.HandlerFunc(server.mergeContacts))
+	router.Handle("GET /api/contacts/{id}", http.HandlerFunc(server.getContactJSON))
+	router.Handle("PUT /api/contacts/{id}", http.HandlerFunc(server.putContactJSON))
+
+	server.Handler = requestID(server.loadSession(server.requireUser(server.reminderBadge(limitBody(server.csrf(router))))))
 
 	return server
 }
@@ -77,13 +279,52 @@ func extractId(r *http.Request) (int, error) {
 func (s *Server) archiveDownload(w http.ResponseWriter, r *http.Request) {
 	job := archiver.GetArchiver().GetJob("user")
 	if job != nil && job.Status() == archiver.StatusComplete && job.Error() == nil {
+		contacts, err := s.exportedContacts(r)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("Content-Disposition", `attachment; filename="contacts.json"`)
-		w.Write([]byte("contact archive"))
+		if err := export.WriteJSON(w, contacts); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// /contacts/export?format=csv or vcf, json by default. ?list= exports the
+// contacts of a smart list.
+func (s *Server) exportContacts(w http.ResponseWriter, r *http.Request) {
+	contacts, err := s.exportedContacts(r)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="contacts.json"`)
+		err = export.WriteJSON(w, contacts)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="contacts.csv"`)
+		err = export.WriteCSV(w, contacts, s.fields.Fields())
+	case "vcf":
+		w.Header().Set("Content-Type", "text/vcard")
+		w.Header().Set("Content-Disposition", `attachment; filename="contacts.vcf"`)
+		err = export.WriteVCard(w, contacts, s.contactPhoto)
+	default:
+		http.Error(w, "unknown export format", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Println(err)
 	}
 }
 
 func (s *Server) archiveStatus(w http.ResponseWriter, r *http.Request) {
-	renderPartial(w, context.Background(), views.Archive(archiver.GetArchiver().GetJob("user")))
+	list, _ := strconv.Atoi(r.URL.Query().Get(views.SmartListFormList))
+	renderPartial(w, context.Background(), views.Archive(archiver.GetArchiver().GetJob("user"), list))
 }
 
 func (s *Server) archive(w http.ResponseWriter, r *http.Request) {
@@ -92,7 +333,8 @@ func (s *Server) archive(w http.ResponseWriter, r *http.Request) {
 	if archiver.GetJob("user") == nil {
 		job = archiver.Archive(context.Background(), "user")
 	}
-	renderPartial(w, context.Background(), views.Archive(job))
+	list, _ := strconv.Atoi(r.FormValue(views.SmartListFormList))
+	renderPartial(w, context.Background(), views.Archive(job, list))
 }
 
 func (s *Server) getCount(w http.ResponseWriter, r *http.Request) {
@@ -116,23 +358,20 @@ func (s *Server) checkEmail(w http.ResponseWriter, r *http.Request) {
 
 // /contacts
 func (s *Server) deleteBulkContact(w http.ResponseWriter, r *http.Request) {
-	idsStr := r.URL.Query()["selected_id"]
-	log.Println(idsStr)
-	for _, str := range idsStr {
-		id, err := strconv.Atoi(str)
-		if err != nil || id <= 0 {
+	var deleted []int
+	for _, id := range s.selectedContacts(r.URL.Query()) {
+		before, err := s.store.GetContact(id)
+		if err != nil {
 			continue
 		}
 		if s.store.DeleteContact(id) != nil {
 			continue
 		}
+		s.recordChange(r, audit.ActionBulkDelete, before, models.Contact{})
+		deleted = append(deleted, id)
 	}
-	contacts, totalPages := s.store.GetContacts(1)
-	viewModel := views.ContactsViewModel{
-		Contacts:   contacts,
-		Pagination: views.NewPagination(1, totalPages, r.URL),
-	}
-	render(w, r.Context(), views.Contacts(viewModel))
+	viewModel := s.contactsViewModel(&url.URL{Path: "/contacts"})
+	render(w, views.WithUndo(r.Context(), deleted), views.Contacts(viewModel))
 }
 
 func (s *Server) deleteContact(w http.ResponseWriter, r *http.Request) {
@@ -141,6 +380,11 @@ func (s *Server) deleteContact(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+	before, err := s.store.GetContact(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
 	if err := s.store.DeleteContact(id); err != nil {
 		switch err {
 		case ErrNotFound:
@@ -150,11 +394,14 @@ func (s *Server) deleteContact(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	s.recordChange(r, audit.ActionDelete, before, models.Contact{})
 	if isInlineDelete(r) {
 		log.Println("client is using inline delete")
-		renderString(w, "")
+		renderPartial(w, r.Context(), views.UndoToast([]int{id}, true))
 		return
 	}
+	// the toast is shown by the page we redirect to
+	sessionFromContext(r.Context()).UndoDelete = []int{id}
 	redirect(w, r, "/contacts")
 }
 
@@ -173,7 +420,43 @@ func (s *Server) editContactPage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "contact not found", http.StatusNotFound)
 		return
 	}
-	render(w, r.Context(), views.ContactEdit(views.ContactFormFromContact(&contact)))
+	s.renderEdit(w, r, views.ContactFormFromContact(&contact))
+}
+
+// renderEdit shows the edit page, filling in what is edited separately
+// from the form itself
+func (s *Server) renderEdit(w http.ResponseWriter, r *http.Request, form *views.ContactForm) {
+	s.fillEditForm(form)
+	render(w, r.Context(), views.ContactEdit(form))
+}
+
+func (s *Server) fillEditForm(form *views.ContactForm) {
+	form.Fields = s.fields.Fields()
+	if contact, err := s.store.GetContact(form.ID); err == nil {
+		form.Photo = contact.Photo
+	}
+	form.Related = s.related(form.ID)
+	if form.Relationship == nil {
+		form.Relationship = &views.RelationshipForm{}
+	}
+}
+
+// renderConflict shows an edit of an older version next to the current
+// one, with the form set to save over it
+func (s *Server) renderConflict(w http.ResponseWriter, r *http.Request, form *views.ContactForm, edit models.Contact) {
+	current, err := s.store.GetContact(form.ID)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	form.Version = current.Version
+	s.fillEditForm(form)
+	w.WriteHeader(http.StatusConflict)
+	render(w, r.Context(), views.ContactConflict(views.ConflictViewModel{
+		Current: current,
+		Changes: models.DiffContacts(current, edit),
+		Form:    form,
+	}))
 }
 
 func (s *Server) editContact(w http.ResponseWriter, r *http.Request) {
@@ -184,15 +467,27 @@ func (s *Server) editContact(w http.ResponseWriter, r *http.Request) {
 	}
 	form := views.ContactFormFromRequest(r)
 	form.ID = id
+	form.Region = s.phoneRegion
+	form.Fields = s.fields.Fields()
 	if !form.Valid() {
-		render(w, r.Context(), views.ContactEdit(form))
+		s.renderEdit(w, r, form)
 		return
 	}
-	if err := s.store.EditContact(*form.ToContact()); err != nil {
+	before, err := s.store.GetContact(id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	after := *form.ToContact()
+	// the photo is changed on its own, see uploadPhoto
+	after.Photo = before.Photo
+	if err := s.store.EditContact(after); err != nil {
 		switch err {
 		case ErrDuplicateEmail:
 			form.Errors.Set(views.ContactFormEmail, err.Error())
-			render(w, r.Context(), views.ContactEdit(form))
+			s.renderEdit(w, r, form)
+		case ErrEditConflict:
+			s.renderConflict(w, r, form, after)
 		case ErrNotFound:
 			http.NotFound(w, r)
 		default:
@@ -200,20 +495,25 @@ func (s *Server) editContact(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	s.recordChange(r, audit.ActionEdit, before, after)
+	s.recordRevision(r, before, after, 0)
 	redirect(w, r, fmt.Sprintf("/contacts/%d", id))
 }
 
 func (s *Server) newContactPage(w http.ResponseWriter, r *http.Request) {
-	render(w, r.Context(), views.NewContact(&views.ContactForm{}))
+	render(w, r.Context(), views.NewContact(&views.ContactForm{Fields: s.fields.Fields()}))
 }
 
 func (s *Server) newContact(w http.ResponseWriter, r *http.Request) {
 	form := views.ContactFormFromRequest(r)
+	form.Region = s.phoneRegion
+	form.Fields = s.fields.Fields()
 	if !form.Valid() {
 		render(w, r.Context(), views.NewContact(form))
 		return
 	}
-	if err := s.store.AddContact(*form.ToContact()); err != nil {
+	contact, err := s.store.AddContact(*form.ToContact())
+	if err != nil {
 		switch err {
 		case ErrDuplicateEmail:
 			form.Errors.Set(views.ContactFormEmail, err.Error())
@@ -223,6 +523,8 @@ func (s *Server) newContact(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	s.recordChange(r, audit.ActionCreate, models.Contact{}, contact)
+	s.recordRevision(r, models.Contact{}, contact, 0)
 	redirect(w, r, "/contacts")
 }
 
@@ -237,33 +539,102 @@ func (s *Server) getContactDetail(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "contact not found", http.StatusNotFound)
 		return
 	}
-	render(w, r.Context(), views.ContactDetail(contact))
+	s.renderDetail(w, r, contact, views.NewReminderForm())
 }
 
-func (s *Server) getContacts(w http.ResponseWriter, r *http.Request) {
-	var (
-		contacts  []models.Contact
-		totalPage int
-	)
-	page, _ := extractPaginationData(r.URL.Query())
-	q := r.URL.Query().Get("q")
-	if q == "" {
-		contacts, totalPage = s.store.GetContacts(page)
-	} else {
-		contacts, totalPage = s.store.FilterContacts(q, page)
-	}
-	data := views.ContactsViewModel{
-		Contacts:   contacts,
-		Query:      q,
-		Pagination: views.NewPagination(page, totalPage, r.URL),
-		ArchiveJob: archiver.GetArchiver().GetJob("user"),
+func (s *Server) renderDetail(w http.ResponseWriter, r *http.Request, contact models.Contact, form *views.ReminderForm) {
+	var reminders []models.Reminder
+	for _, reminder := range s.reminders.Reminders(contact.ID) {
+		if !reminder.Done {
+			reminders = append(reminders, reminder)
+		}
 	}
+	render(w, r.Context(), views.ContactDetail(views.ContactDetailViewModel{
+		Contact:      contact,
+		Fields:       s.fields.Fields(),
+		Related:      s.related(contact.ID),
+		Reminders:    reminders,
+		ReminderForm: form,
+	}))
+}
+
+func (s *Server) getContacts(w http.ResponseWriter, r *http.Request) {
+	data := s.contactsViewModel(r.URL)
 	if isActiveSearch(r) {
 		log.Println("client hit us with a active search request")
-		renderPartial(w, r.Context(), views.Rows(contacts, data.Pagination))
+		renderPartial(w, r.Context(), views.ActiveSearchResults(data))
 		return
 	}
-	render(w, r.Context(), views.Contacts(data))
+	ctx := r.Context()
+	if session := sessionFromContext(ctx); len(session.UndoDelete) > 0 {
+		ctx = views.WithUndo(ctx, session.UndoDelete)
+		session.UndoDelete = nil
+	}
+	render(w, ctx, views.Contacts(data))
+}
+
+// contactsViewModel searches the contacts the way the query parameters of
+// u say
+func (s *Server) contactsViewModel(u *url.URL) views.ContactsViewModel {
+	var result models.ContactPage
+	values, current := u.Query(), *u
+	q, tag := values.Get("q"), models.NormalizeTag(values.Get("tag"))
+	// ?untouched=30 lists the contacts nobody got in touch with for 30 days
+	untouched, _ := strconv.Atoi(values.Get("untouched"))
+	untouched = max(untouched, 0)
+	var suggestion, queryError string
+	filter, parsed, err := contactFilter(q, tag, untouched)
+	filter.Sort = models.ParseSort(values.Get("sort"), values.Get("order"))
+	page := extractPage(values, filter.Sort)
+	switch {
+	case err != nil:
+		queryError = err.Error()
+	case filter.Empty():
+		result = s.store.GetContacts(page)
+	default:
+		result = s.store.FilterContacts(filter, page)
+	}
+	// nothing found, show what a corrected query finds instead
+	if len(result.Contacts) == 0 && err == nil && parsed.Plain() && filter.Query != "" {
+		if suggestion = s.store.SuggestQuery(filter.Query); suggestion != "" {
+			filter.Query = suggestion
+			result = s.store.FilterContacts(filter, page)
+		}
+	}
+	pagination := views.NewPagination(page.Number, result.TotalPages, u)
+	if result.Next != nil {
+		pagination.Cursor = result.Next.String()
+	}
+	return views.ContactsViewModel{
+		Contacts:   result.Contacts,
+		Query:      q,
+		QueryError: queryError,
+		Suggestion: suggestion,
+		Highlight:  filter.Query,
+		Tag:        tag,
+		Untouched:  untouched,
+		Sort:       filter.Sort,
+		URL:        &current,
+		Groups:     s.groups.Groups(),
+		SmartLists: s.smartListCounts(),
+		List:       s.activeSmartList(values),
+		ListForm:   &views.SmartListForm{},
+		Pagination: pagination,
+		ArchiveJob: archiver.GetArchiver().GetJob("user"),
+	}
+}
+
+// contactFilter is the filter of the search box q, a tag and the days since
+// contacts were last contacted. parsed is nil when q isn't a valid query.
+func contactFilter(q, tag string, untouched int) (filter models.ContactFilter, parsed *query.Query, err error) {
+	filter.Tag = tag
+	if untouched > 0 {
+		filter.NotContactedSince = time.Now().AddDate(0, 0, -untouched)
+	}
+	if parsed, err = query.Parse(q); err != nil {
+		return filter, nil, err
+	}
+	return parsed.Plan(filter), parsed, nil
 }
 
 func render(w http.ResponseWriter, ctx context.Context, content templ.Component) {
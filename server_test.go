@@ -6,10 +6,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/phone"
 	"github.com/rezbow/contact-app/views"
 	"github.com/sebdah/goldie"
 )
@@ -20,6 +24,8 @@ type StubContactStore struct {
 	editCalls   []models.Contact
 	deleteCalls []int
 	idSeq       int
+	// returned by EditContact, e.g. to act out a concurrent edit
+	editErr error
 }
 
 func (s *StubContactStore) nextId() int {
@@ -30,14 +36,18 @@ func (s *StubContactStore) Count() int {
 	return 0
 }
 
-func (s *StubContactStore) AddContact(contact models.Contact) error {
+func (s *StubContactStore) AddContact(contact models.Contact) (models.Contact, error) {
 	contact.ID = s.nextId()
 	s.addCalls = append(s.addCalls, contact)
-	return nil
+	return contact, nil
+}
+
+func (s *StubContactStore) GetContacts(page models.Page) models.ContactPage {
+	return models.ContactPage{Contacts: s.contacts}
 }
 
-func (s *StubContactStore) GetContacts(page int) ([]models.Contact, int) {
-	return s.contacts, 0
+func (s *StubContactStore) AllContacts() []models.Contact {
+	return s.contacts
 }
 
 func (s *StubContactStore) GetContact(id int) (models.Contact, error) {
@@ -49,8 +59,12 @@ func (s *StubContactStore) GetContact(id int) (models.Contact, error) {
 	return models.Contact{}, errors.New("contact not found ")
 }
 
-func (s *StubContactStore) FilterContacts(q string, page int) ([]models.Contact, int) {
-	return s.contacts, 0
+func (s *StubContactStore) FilterContacts(filter models.ContactFilter, page models.Page) models.ContactPage {
+	return models.ContactPage{Contacts: s.contacts}
+}
+
+func (s *StubContactStore) SuggestQuery(query string) string {
+	return ""
 }
 
 func (s *StubContactStore) DuplicateEmail(email string, id int) bool {
@@ -59,6 +73,10 @@ func (s *StubContactStore) DuplicateEmail(email string, id int) bool {
 
 func (s *StubContactStore) EditContact(contact models.Contact) error {
 	s.editCalls = append(s.editCalls, contact)
+	return s.editErr
+}
+
+func (s *StubContactStore) SetLastContacted(id int, t time.Time) error {
 	return nil
 }
 
@@ -67,6 +85,49 @@ func (s *StubContactStore) DeleteContact(id int) error {
 	return nil
 }
 
+func (s *StubContactStore) GetTrash() []models.Contact {
+	return nil
+}
+
+func (s *StubContactStore) EditTrashedContact(contact models.Contact) error {
+	return ErrNotFound
+}
+
+func (s *StubContactStore) RestoreContact(id int) error {
+	return nil
+}
+
+func (s *StubContactStore) PurgeContact(id int) error {
+	return nil
+}
+
+func (s *StubContactStore) PurgeDeletedBefore(t time.Time) []models.Contact {
+	return nil
+}
+
+const (
+	testCSRFToken = "test-csrf-token"
+	testUserEmail = "tester@example.com"
+)
+
+func withSession(req *http.Request, session *Session) *http.Request {
+	req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: session.ID})
+	req.Header.Set(views.CSRFHeaderName, session.CSRFToken)
+	return req
+}
+
+// signedIn starts a session of the test user of server, which every page but
+// the login needs
+func signedIn(server *Server) *Session {
+	user, err := server.users.GetUserByEmail(testUserEmail)
+	if err != nil {
+		user, _ = server.users.AddUser(models.User{Email: testUserEmail, Name: "Tester"})
+	}
+	session := server.sessions.New()
+	session.UserID = user.ID
+	return session
+}
+
 func newGetRequest(path string) *http.Request {
 	req, _ := http.NewRequest(http.MethodGet, path, nil)
 	return req
@@ -107,17 +168,20 @@ func assertRedirect(t testing.TB, res *httptest.ResponseRecorder, url string) {
 func TestServer(t *testing.T) {
 	store := &StubContactStore{
 		contacts: []models.Contact{
-			{ID: 1, FirstName: "Chris", LastName: "Jackson", PhoneNumber: "92213", Email: "ChrisJackson@email.com"},
-			{ID: 2, FirstName: "John", LastName: "Doe", PhoneNumber: "754639", Email: "JohnDoe@email.com"},
+			{ID: 1, FirstName: "Chris", LastName: "Jackson", Phones: phones("92213"), Emails: emails("ChrisJackson@email.com")},
+			{ID: 2, FirstName: "John", LastName: "Doe", Phones: phones("754639"), Emails: emails("JohnDoe@email.com")},
 		},
 		idSeq: 2,
 	}
 	server := NewContactServer(store)
+	server.sessions.newToken = func() string { return testCSRFToken }
+	session := signedIn(server)
+
 	t.Run("request to contacts returns all contacts", func(t *testing.T) {
 		req := newGetRequest("/contacts")
 		res := httptest.NewRecorder()
 
-		server.ServeHTTP(res, req)
+		server.ServeHTTP(res, withSession(req, session))
 		assertCode(t, res.Code, http.StatusOK)
 		assertGolden(t, res.Body.Bytes())
 	})
@@ -125,7 +189,7 @@ func TestServer(t *testing.T) {
 	t.Run("request to contacts with search query 'Chris' returns correct contacts", func(t *testing.T) {
 		req := newGetRequestWithQuery("/contacts", "Chris")
 		res := httptest.NewRecorder()
-		server.ServeHTTP(res, req)
+		server.ServeHTTP(res, withSession(req, session))
 
 		assertCode(t, res.Code, http.StatusOK)
 		assertGolden(t, res.Body.Bytes())
@@ -134,27 +198,27 @@ func TestServer(t *testing.T) {
 	t.Run("request to an undefined route returns 404 code", func(t *testing.T) {
 		req := newGetRequest("/undefined")
 		res := httptest.NewRecorder()
-		server.ServeHTTP(res, req)
+		server.ServeHTTP(res, withSession(req, session))
 		assertCode(t, res.Code, http.StatusNotFound)
 	})
 
 	t.Run("get new contact page(form) ", func(t *testing.T) {
 		req := newGetRequest("/contacts/new")
 		res := httptest.NewRecorder()
-		server.ServeHTTP(res, req)
+		server.ServeHTTP(res, withSession(req, session))
 		assertCode(t, res.Code, http.StatusOK)
 		assertGolden(t, res.Body.Bytes())
 	})
 
 	t.Run("add new contact", func(t *testing.T) {
 		contact := models.Contact{
-			ID:          store.idSeq + 1,
-			FirstName:   "Reza",
-			LastName:    "Bolhasani",
-			PhoneNumber: "093223323",
-			Email:       "rez@gmail.com",
+			ID:        store.idSeq + 1,
+			FirstName: "Reza",
+			LastName:  "Bolhasani",
+			Phones:    phones("(415) 555-0123"),
+			Emails:    emails("rez@gmail.com"),
 		}
-		req := newContactRequest(contact)
+		req := withSession(newContactRequest(contact), session)
 		res := httptest.NewRecorder()
 
 		server.ServeHTTP(res, req)
@@ -164,30 +228,47 @@ func TestServer(t *testing.T) {
 			t.Fatalf("got %d call to AddContact, wanted %d ", len(store.addCalls), 1)
 		}
 
-		if store.addCalls[0] != contact {
+		if !reflect.DeepEqual(store.addCalls[0], contact) {
 			t.Errorf("%v added to store, wanted %v", store.addCalls[0], contact)
 		}
 	})
 
 	t.Run("error on invalid new contact form ", func(t *testing.T) {
 		contact := models.Contact{
-			FirstName:   "",
-			LastName:    "Bolhasani",
-			PhoneNumber: "",
-			Email:       "rez@gmail.com",
+			FirstName: "",
+			LastName:  "Bolhasani",
+			Emails:    emails("rez@gmail.com"),
 		}
-		req := newContactRequest(contact)
+		req := withSession(newContactRequest(contact), session)
 		res := httptest.NewRecorder()
 
 		server.ServeHTTP(res, req)
 		assertGolden(t, res.Body.Bytes())
 	})
 
+	t.Run("blank rows for multi-valued fields", func(t *testing.T) {
+		for kind, name := range map[string]string{
+			"phone":   views.ContactFormPhone,
+			"email":   views.ContactFormEmail,
+			"address": views.ContactFormAddressStreet,
+		} {
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, withSession(newGetRequest("/form-rows/"+kind), session))
+			assertCode(t, res.Code, http.StatusOK)
+			if !strings.Contains(res.Body.String(), fmt.Sprintf("name=%q", name)) {
+				t.Errorf("expected %s row to have an input named %q", kind, name)
+			}
+		}
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(newGetRequest("/form-rows/unknown"), session))
+		assertCode(t, res.Code, http.StatusNotFound)
+	})
+
 	t.Run("get contact detail", func(t *testing.T) {
 		id := 1
 		req := newGetRequest(fmt.Sprintf("/contacts/%d", id))
 		res := httptest.NewRecorder()
-		server.ServeHTTP(res, req)
+		server.ServeHTTP(res, withSession(req, session))
 
 		assertCode(t, res.Code, http.StatusOK)
 		assertGolden(t, res.Body.Bytes())
@@ -196,7 +277,7 @@ func TestServer(t *testing.T) {
 	t.Run("return 404 for missing contact", func(t *testing.T) {
 		req := newGetRequest(fmt.Sprintf("/contacts/%d", 32329))
 		res := httptest.NewRecorder()
-		server.ServeHTTP(res, req)
+		server.ServeHTTP(res, withSession(req, session))
 
 		assertCode(t, res.Code, http.StatusNotFound)
 		assertGolden(t, res.Body.Bytes())
@@ -205,7 +286,7 @@ func TestServer(t *testing.T) {
 	t.Run("edit contact page", func(t *testing.T) {
 		req := newGetRequest(fmt.Sprintf("/contacts/%d/edit", 1))
 		res := httptest.NewRecorder()
-		server.ServeHTTP(res, req)
+		server.ServeHTTP(res, withSession(req, session))
 
 		assertCode(t, res.Code, http.StatusOK)
 		assertGolden(t, res.Body.Bytes())
@@ -216,10 +297,10 @@ func TestServer(t *testing.T) {
 		contact := store.contacts[0]
 		contact.FirstName = "Charles"
 		contact.LastName = "White"
-		contact.PhoneNumber = "091020"
-		contact.Email = "Charles@white.com"
+		contact.Phones = phones("+44 7400 123456")
+		contact.Emails = emails("Charles@white.com")
 
-		req := editContactRequest(contact)
+		req := withSession(editContactRequest(contact), session)
 		res := httptest.NewRecorder()
 		server.ServeHTTP(res, req)
 
@@ -229,7 +310,7 @@ func TestServer(t *testing.T) {
 			t.Fatalf("call to edit must be %d, got %d", 1, len(store.editCalls))
 		}
 
-		if store.editCalls[0] != contact {
+		if !reflect.DeepEqual(store.editCalls[0], contact) {
 			t.Errorf("edit contact recived wrong argument, got %v, wanted %v", store.editCalls[0], contact)
 		}
 
@@ -237,6 +318,7 @@ func TestServer(t *testing.T) {
 
 	t.Run("delete contact", func(t *testing.T) {
 		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/contacts/%d", 1), nil)
+		withSession(req, session)
 		res := httptest.NewRecorder()
 		server.ServeHTTP(res, req)
 
@@ -252,6 +334,32 @@ func TestServer(t *testing.T) {
 	})
 }
 
+func TestContactDetailNotes(t *testing.T) {
+	store := &StubContactStore{contacts: []models.Contact{{
+		ID: 1, FirstName: "Chris", LastName: "Jackson",
+		Company:  "Acme",
+		JobTitle: "CTO",
+		Birthday: time.Date(1980, 3, 4, 0, 0, 0, 0, time.UTC),
+		Website:  "https://chris.dev",
+		Notes:    "**VIP** <script>alert(1)</script> [x](javascript:alert(1))",
+	}}}
+	server := NewContactServer(store)
+	session := signedIn(server)
+	res := httptest.NewRecorder()
+	server.ServeHTTP(res, withSession(newGetRequest("/contacts/1"), session))
+	body := res.Body.String()
+	for _, want := range []string{"CTO, Acme", "March 4, 1980", `href="https://chris.dev"`, "<strong>VIP</strong>", "&lt;script&gt;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected detail page to contain %q", want)
+		}
+	}
+	for _, bad := range []string{"<script>alert", "javascript:"} {
+		if strings.Contains(body, bad) {
+			t.Errorf("detail page contains unsanitized %q", bad)
+		}
+	}
+}
+
 func editContactRequest(contact models.Contact) *http.Request {
 	body := strings.NewReader(contactToForm(contact))
 	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/contacts/%d/edit", contact.ID), body)
@@ -263,7 +371,175 @@ func contactToForm(contact models.Contact) string {
 	f := url.Values{}
 	f.Set(views.ContactFormFirstName, contact.FirstName)
 	f.Set(views.ContactFormLastName, contact.LastName)
-	f.Set(views.ContactFormPhone, contact.PhoneNumber)
-	f.Set(views.ContactFormEmail, contact.Email)
+	f.Set(views.ContactFormVersion, strconv.Itoa(contact.Version))
+	for _, phone := range contact.Phones {
+		f.Add(views.ContactFormPhoneLabel, phone.Label)
+		f.Add(views.ContactFormPhone, phone.Number)
+	}
+	for _, email := range contact.Emails {
+		f.Add(views.ContactFormEmailLabel, email.Label)
+		f.Add(views.ContactFormEmail, email.Address)
+	}
+	for _, address := range contact.Addresses {
+		f.Add(views.ContactFormAddressLabel, address.Label)
+		f.Add(views.ContactFormAddressStreet, address.Street)
+		f.Add(views.ContactFormAddressCity, address.City)
+		f.Add(views.ContactFormAddressRegion, address.Region)
+		f.Add(views.ContactFormAddressPostalCode, address.PostalCode)
+		f.Add(views.ContactFormAddressCountry, address.Country)
+	}
+	for key, value := range contact.Custom {
+		f.Set(views.CustomFieldName(key), value)
+	}
 	return f.Encode()
 }
+
+// phones as the server keeps them, in E.164 too when they parse
+func phones(numbers ...string) []models.Phone {
+	var phones []models.Phone
+	for _, number := range numbers {
+		p := models.Phone{Label: "mobile", Number: number}
+		if n, err := phone.Parse(number, defaultPhoneRegion); err == nil {
+			p.E164 = n.E164()
+		}
+		phones = append(phones, p)
+	}
+	return phones
+}
+
+func emails(addresses ...string) []models.Email {
+	var emails []models.Email
+	for _, address := range addresses {
+		emails = append(emails, models.Email{Label: "home", Address: address})
+	}
+	return emails
+}
+
+func TestCSRF(t *testing.T) {
+	store := &StubContactStore{
+		contacts: []models.Contact{
+			{ID: 1, FirstName: "Chris", LastName: "Jackson", Phones: phones("92213"), Emails: emails("ChrisJackson@email.com")},
+		},
+		idSeq: 1,
+	}
+	server := NewContactServer(store)
+	session := signedIn(server)
+
+	contact := models.Contact{ID: 1, FirstName: "Reza", LastName: "Bolhasani", Phones: phones("(415) 555-0123"), Emails: emails("rez@gmail.com")}
+	routes := []struct {
+		name string
+		req  func() *http.Request
+	}{
+		{"new contact", func() *http.Request { return newContactRequest(contact) }},
+		{"edit contact", func() *http.Request { return editContactRequest(contact) }},
+		{"delete contact", func() *http.Request {
+			req, _ := http.NewRequest(http.MethodDelete, "/contacts/1", nil)
+			return req
+		}},
+		{"bulk delete", func() *http.Request {
+			req, _ := http.NewRequest(http.MethodDelete, "/contacts?selected_id=1", nil)
+			return req
+		}},
+		{"archive", func() *http.Request {
+			req, _ := http.NewRequest(http.MethodPost, "/contacts/archive", nil)
+			return req
+		}},
+	}
+
+	for _, route := range routes {
+		t.Run(route.name+" without token is forbidden", func(t *testing.T) {
+			req := route.req()
+			req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: session.ID})
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, req)
+			assertCode(t, res.Code, http.StatusForbidden)
+		})
+
+		t.Run(route.name+" with mismatched token is forbidden", func(t *testing.T) {
+			req := withSession(route.req(), session)
+			req.Header.Set(views.CSRFHeaderName, "not-the-token")
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, req)
+			assertCode(t, res.Code, http.StatusForbidden)
+		})
+
+		t.Run(route.name+" with token of another session is forbidden", func(t *testing.T) {
+			other := server.sessions.New()
+			req := withSession(route.req(), session)
+			req.Header.Set(views.CSRFHeaderName, other.CSRFToken)
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, req)
+			assertCode(t, res.Code, http.StatusForbidden)
+		})
+	}
+
+	if len(store.addCalls) != 0 || len(store.editCalls) != 0 || len(store.deleteCalls) != 0 {
+		t.Errorf("store was modified by forbidden requests")
+	}
+
+	t.Run("token in form body is accepted", func(t *testing.T) {
+		f, _ := url.ParseQuery(contactToForm(contact))
+		f.Set(views.CSRFFieldName, session.CSRFToken)
+		req, _ := http.NewRequest(http.MethodPost, "/contacts/new", strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: session.ID})
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, req)
+		assertRedirect(t, res, "/contacts")
+	})
+
+	t.Run("get request sets session cookie and embeds token", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, newGetRequest("/login"))
+		assertCode(t, res.Code, http.StatusOK)
+
+		cookies := res.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != sessionCookieName {
+			t.Fatalf("expected a %q cookie, got %v", sessionCookieName, cookies)
+		}
+		token := server.sessions.Get(cookies[0].Value).CSRFToken
+		if !strings.Contains(res.Body.String(), token) {
+			t.Errorf("expected page to contain csrf token %q", token)
+		}
+	})
+}
+
+func TestSessions(t *testing.T) {
+	server := NewContactServer(&StubContactStore{}, WithSessionIdleTimeout(time.Hour))
+	now := time.Now()
+	server.sessions.now = func() time.Time { return now }
+
+	t.Run("only pages that need a session start one", func(t *testing.T) {
+		for _, path := range []string{"/contacts", "/static/site.css"} {
+			res := httptest.NewRecorder()
+			server.ServeHTTP(res, newGetRequest(path))
+			if cookies := res.Result().Cookies(); len(cookies) != 0 {
+				t.Errorf("expected no session for %s, got %v", path, cookies)
+			}
+		}
+		if len(server.sessions.sessions) != 0 {
+			t.Errorf("got %d sessions, wanted none", len(server.sessions.sessions))
+		}
+	})
+
+	t.Run("idle sessions expire", func(t *testing.T) {
+		used, idle := signedIn(server), signedIn(server)
+		now = now.Add(50 * time.Minute)
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(newGetRequest("/contacts"), used))
+		assertCode(t, res.Code, http.StatusOK)
+
+		now = now.Add(20 * time.Minute)
+		res = httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(newGetRequest("/contacts"), idle))
+		assertRedirect(t, res, "/login")
+		if server.sessions.Get(used.ID) == nil {
+			t.Errorf("expected the session used 20 minutes ago to live on")
+		}
+
+		now = now.Add(61 * time.Minute)
+		if n := server.sessions.DeleteIdle(); n != 1 {
+			t.Errorf("dropped %d idle sessions, wanted 1", n)
+		}
+	})
+}
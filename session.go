@@ -0,0 +1,205 @@
+package contactapp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rezbow/contact-app/views"
+)
+
+const sessionCookieName = "session"
+
+// how long a session lasts without being used
+const defaultSessionIdleTimeout = 24 * time.Hour
+
+type Session struct {
+	ID        string
+	CSRFToken string
+	// zero while nobody is logged in
+	UserID int
+
+	// pending sso login, checked when the provider redirects back
+	OIDCState    string
+	OIDCNonce    string
+	OIDCVerifier string
+
+	// contacts deleted by the last request, offered for undo on the next page
+	UndoDelete []int
+
+	lastSeen time.Time
+}
+
+type SessionStore struct {
+	mu          sync.Mutex
+	sessions    map[string]*Session
+	newToken    func() string
+	now         func() time.Time
+	idleTimeout time.Duration
+}
+
+func NewSessionStore() *SessionStore {
+	return &SessionStore{
+		sessions:    make(map[string]*Session),
+		newToken:    randomToken,
+		now:         time.Now,
+		idleTimeout: defaultSessionIdleTimeout,
+	}
+}
+
+func (s *SessionStore) New() *Session {
+	session := &Session{
+		ID:        s.newToken(),
+		CSRFToken: s.newToken(),
+	}
+	s.mu.Lock()
+	session.lastSeen = s.now()
+	s.sessions[session.ID] = session
+	s.mu.Unlock()
+	return session
+}
+
+// Get returns the session with id and keeps it alive, nil if there is none
+// or it has been idle for too long
+func (s *SessionStore) Get(id string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := s.sessions[id]
+	if session == nil {
+		return nil
+	}
+	if s.idle(session) {
+		delete(s.sessions, id)
+		return nil
+	}
+	session.lastSeen = s.now()
+	return session
+}
+
+func (s *SessionStore) idle(session *Session) bool {
+	return s.now().Sub(session.lastSeen) > s.idleTimeout
+}
+
+// DeleteIdle drops the sessions that have been idle for too long and
+// returns how many there were
+func (s *SessionStore) DeleteIdle() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for id, session := range s.sessions {
+		if s.idle(session) {
+			delete(s.sessions, id)
+			count++
+		}
+	}
+	return count
+}
+
+func (s *SessionStore) Delete(id string) {
+	s.mu.Lock()
+	delete(s.sessions, id)
+	s.mu.Unlock()
+}
+
+// Load returns the session of the request, nil if it has none
+func (s *SessionStore) Load(r *http.Request) *Session {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return nil
+	}
+	return s.Get(cookie.Value)
+}
+
+// Renew replaces old with a fresh session for userID, so a session id seen
+// before login can't be used after it (session fixation).
+func (s *SessionStore) Renew(w http.ResponseWriter, old *Session, userID int) *Session {
+	if old != nil {
+		s.Delete(old.ID)
+	}
+	session := s.New()
+	session.UserID = userID
+	setSessionCookie(w, session)
+	return session
+}
+
+func setSessionCookie(w http.ResponseWriter, session *Session) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    session.ID,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+type sessionKey struct{}
+
+func sessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionKey{}).(*Session)
+	return session
+}
+
+// loadSession attaches the session, if the request has one, and its user to
+// the request context, along with the phone region the views format numbers
+// for. sessions are only started where one is needed, see startSession.
+func (s *Server) loadSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := views.WithPhoneRegion(r.Context(), s.phoneRegion)
+		if session := s.sessions.Load(r); session != nil {
+			ctx = s.withSession(ctx, session)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// startSession starts a session for visitors who don't have one yet, for the
+// pages that need a csrf token or keep state before anyone is logged in
+func (s *Server) startSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sessionFromContext(r.Context()) == nil {
+			session := s.sessions.New()
+			setSessionCookie(w, session)
+			r = r.WithContext(s.withSession(r.Context(), session))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) withSession(ctx context.Context, session *Session) context.Context {
+	ctx = context.WithValue(ctx, sessionKey{}, session)
+	ctx = views.WithCSRFToken(ctx, session.CSRFToken)
+	if session.UserID != 0 {
+		if user, err := s.users.GetUser(session.UserID); err == nil {
+			ctx = views.WithUser(ctx, &user)
+		}
+	}
+	return ctx
+}
+
+// RunSessionCleanup drops idle sessions every interval until ctx is done
+func (s *Server) RunSessionCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n := s.sessions.DeleteIdle(); n > 0 {
+				log.Printf("dropped %d idle sessions", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package contactapp
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+// /lists saves the search of the contact list as a smart list
+func (s *Server) newSmartList(w http.ResponseWriter, r *http.Request) {
+	form := views.SmartListFormFromRequest(r)
+	if form.Valid() {
+		if _, _, err := contactFilter(form.Query, form.Tag, form.Untouched); err != nil {
+			form.Errors.Set(views.SmartListFormName, "can't save a search with errors")
+		}
+	}
+	if len(form.Errors) > 0 {
+		s.renderSmartListForm(w, r, form)
+		return
+	}
+	list, err := s.lists.AddSmartList(form.ToSmartList())
+	if err != nil {
+		switch err {
+		case ErrDuplicateSmartList:
+			form.Errors.Set(views.SmartListFormName, err.Error())
+			s.renderSmartListForm(w, r, form)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	redirect(w, r, list.URL())
+}
+
+// renderSmartListForm shows the contact list of the search being saved with
+// what's wrong with the form
+func (s *Server) renderSmartListForm(w http.ResponseWriter, r *http.Request, form *views.SmartListForm) {
+	search := models.SmartList{Query: form.Query, Tag: form.Tag, Untouched: form.Untouched}
+	data := s.contactsViewModel(&url.URL{Path: "/contacts", RawQuery: search.Values().Encode()})
+	data.ListForm = form
+	render(w, r.Context(), views.Contacts(data))
+}
+
+// /lists/{id}
+func (s *Server) deleteSmartList(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := s.lists.DeleteSmartList(id); err != nil {
+		switch err {
+		case ErrSmartListNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	renderString(w, "")
+}
+
+// /lists is the sidebar of the contact list, polled to keep the counts live
+func (s *Server) getSmartLists(w http.ResponseWriter, r *http.Request) {
+	active, _ := strconv.Atoi(r.URL.Query().Get(views.SmartListFormList))
+	renderPartial(w, r.Context(), views.SmartLists(s.smartListCounts(), active))
+}
+
+// smartListContacts are the contacts matching the search of a list now, in
+// list order. a search that no longer parses matches nothing.
+func (s *Server) smartListContacts(list models.SmartList) []models.Contact {
+	filter, _, err := contactFilter(list.Query, list.Tag, list.Untouched)
+	if err != nil {
+		return nil
+	}
+	var contacts []models.Contact
+	for _, contact := range s.store.AllContacts() {
+		if filter.Matches(contact) {
+			contacts = append(contacts, contact)
+		}
+	}
+	return contacts
+}
+
+func (s *Server) smartListCounts() []views.SmartListCount {
+	var counts []views.SmartListCount
+	for _, list := range s.lists.SmartLists() {
+		counts = append(counts, views.SmartListCount{
+			SmartList: list,
+			Count:     len(s.smartListContacts(list)),
+		})
+	}
+	return counts
+}
+
+// activeSmartList is the list named by ?list=, the zero list when there is
+// none
+func (s *Server) activeSmartList(values url.Values) views.SmartListCount {
+	id, _ := strconv.Atoi(values.Get(views.SmartListFormList))
+	list, err := s.lists.GetSmartList(id)
+	if err != nil {
+		return views.SmartListCount{}
+	}
+	return views.SmartListCount{SmartList: list, Count: len(s.smartListContacts(list))}
+}
+
+// selectedContacts are the ids of the contacts checked for a bulk action,
+// along with every contact of the smart list the action is given, if any
+func (s *Server) selectedContacts(values url.Values) []int {
+	var ids []int
+	seen := make(map[int]bool)
+	add := func(id int) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, str := range values["selected_id"] {
+		id, err := strconv.Atoi(str)
+		if err != nil || id <= 0 {
+			continue
+		}
+		add(id)
+	}
+	if id, err := strconv.Atoi(values.Get(views.SmartListFormList)); err == nil {
+		if list, err := s.lists.GetSmartList(id); err == nil {
+			for _, contact := range s.smartListContacts(list) {
+				add(contact.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// exportedContacts are what exports and archives of r hold: the contacts of
+// the smart list of ?list=, or every contact
+func (s *Server) exportedContacts(r *http.Request) ([]models.Contact, error) {
+	str := r.URL.Query().Get(views.SmartListFormList)
+	if str == "" {
+		return s.store.AllContacts(), nil
+	}
+	id, _ := strconv.Atoi(str)
+	list, err := s.lists.GetSmartList(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.smartListContacts(list), nil
+}
@@ -0,0 +1,124 @@
+package contactapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestSmartLists(t *testing.T) {
+	store := NewinMemoryStore()
+	lists := NewInMemorySmartListStore()
+	groups := NewInMemoryGroupStore()
+	server := NewContactServer(store, WithSmartListStore(lists), WithGroupStore(groups))
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	post := func(path string, f url.Values) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+
+	t.Run("save the search of the contact list", func(t *testing.T) {
+		res := post("/lists", url.Values{"name": {"Jacks"}, "q": {"email:*jaskcons* OR last:morgan"}})
+		assertRedirect(t, res, "/contacts?list=1&q=email%3A%2Ajaskcons%2A+OR+last%3Amorgan")
+		want := models.SmartList{ID: 1, Name: "Jacks", Query: "email:*jaskcons* OR last:morgan"}
+		if got, _ := lists.GetSmartList(1); got != want {
+			t.Errorf("got %+v, wanted %+v", got, want)
+		}
+	})
+
+	t.Run("saving needs a name, a search and a valid query", func(t *testing.T) {
+		for _, form := range []url.Values{
+			{"name": {""}, "q": {"john"}},
+			{"name": {"Everyone"}},
+			{"name": {"Broken"}, "q": {"(john"}},
+			{"name": {"jacks"}, "q": {"jack"}},
+		} {
+			res := post("/lists", form)
+			assertCode(t, res.Code, http.StatusOK)
+			if !strings.Contains(res.Body.String(), `id="save-smart-list"`) {
+				t.Errorf("expected the form again for %v", form)
+			}
+		}
+		if got := len(lists.SmartLists()); got != 1 {
+			t.Errorf("got %d lists, wanted 1", got)
+		}
+	})
+
+	t.Run("sidebar counts contacts matching now", func(t *testing.T) {
+		body := serve(newGetRequest("/lists")).Body.String()
+		if !strings.Contains(body, "Jacks</a> <span class=\"count\">2</span>") {
+			t.Errorf("expected a count of 2, got %s", body)
+		}
+		store.DeleteContact(3)
+		body = serve(newGetRequest("/lists")).Body.String()
+		if !strings.Contains(body, "Jacks</a> <span class=\"count\">1</span>") {
+			t.Errorf("expected a count of 1 after a delete, got %s", body)
+		}
+		store.RestoreContact(3)
+	})
+
+	t.Run("contact list offers acting on the whole list", func(t *testing.T) {
+		body := serve(newGetRequest("/contacts?list=1&q=jack")).Body.String()
+		for _, want := range []string{
+			`name="list" value="1"`,
+			"All 2 contacts of Jacks",
+			`href="/contacts/export?format=csv&amp;list=1"`,
+			`hx-post="/contacts/archive?list=1"`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected %q on the page", want)
+			}
+		}
+	})
+
+	t.Run("bulk actions take the contacts of a list", func(t *testing.T) {
+		groups.AddGroup(models.Group{Name: "Newsletter"})
+		res := post("/groups/members", url.Values{"group": {"1"}, "selected_id": {"2", "3"}, "list": {"1"}})
+		assertRedirect(t, res, "/groups/1")
+		group, _ := groups.GetGroup(1)
+		if want := []int{2, 3, 1}; !reflect.DeepEqual(group.Members, want) {
+			t.Errorf("got members %v, wanted %v", group.Members, want)
+		}
+	})
+
+	t.Run("export the contacts of a list", func(t *testing.T) {
+		res := serve(newGetRequest("/contacts/export?format=csv&list=1"))
+		body := res.Body.String()
+		if !strings.Contains(body, "Jack") || !strings.Contains(body, "Morgan") || strings.Contains(body, "Doe") {
+			t.Errorf("expected only the contacts of the list, got %s", body)
+		}
+		assertCode(t, serve(newGetRequest("/contacts/export?list=99")).Code, http.StatusNotFound)
+	})
+
+	t.Run("active search updates the search to save", func(t *testing.T) {
+		req := newGetRequestWithQuery("/contacts", "doe")
+		req.Header.Set("HX-Trigger", "search")
+		body := serve(req).Body.String()
+		if !strings.Contains(body, `<form id="save-smart-list" action="/lists" method="post" hx-swap-oob="true">`) ||
+			!strings.Contains(body, `name="q" value="doe"`) {
+			t.Errorf("expected the save form out of band, got %s", body)
+		}
+	})
+
+	t.Run("delete a list", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/lists/1", nil)
+		assertCode(t, serve(req).Code, http.StatusOK)
+		if _, err := lists.GetSmartList(1); err != ErrSmartListNotFound {
+			t.Errorf("got %v, wanted %v", err, ErrSmartListNotFound)
+		}
+		req, _ = http.NewRequest(http.MethodDelete, "/lists/1", nil)
+		assertCode(t, serve(req).Code, http.StatusNotFound)
+	})
+}
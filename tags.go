@@ -0,0 +1,94 @@
+package contactapp
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+const maxTagSuggestions = 10
+
+// /tags
+func (s *Server) getTags(w http.ResponseWriter, r *http.Request) {
+	render(w, r.Context(), views.Tags(views.TagsViewModel{Tags: models.CountTags(s.store.AllContacts())}))
+}
+
+// /tags/suggest?tag=fa lists existing tags starting with the typed text, as
+// options of the tag editor's datalist
+func (s *Server) suggestTags(w http.ResponseWriter, r *http.Request) {
+	prefix := models.NormalizeTag(r.URL.Query().Get("tag"))
+	var names []string
+	for _, tag := range models.CountTags(s.store.AllContacts()) {
+		if strings.HasPrefix(tag.Name, prefix) && len(names) < maxTagSuggestions {
+			names = append(names, tag.Name)
+		}
+	}
+	renderPartial(w, r.Context(), views.TagOptions(names))
+}
+
+// /tags/{name}/rename renames a tag on every contact. renaming to a tag that
+// is already in use merges the two.
+func (s *Server) renameTag(w http.ResponseWriter, r *http.Request) {
+	from := models.NormalizeTag(r.PathValue("name"))
+	to := models.NormalizeTag(r.PostFormValue("name"))
+	if to == "" || len(to) > models.MaxTagLength {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render(w, r.Context(), views.Tags(views.TagsViewModel{
+			Tags:  models.CountTags(s.store.AllContacts()),
+			Error: fmt.Sprintf("a tag needs a name of at most %d characters", models.MaxTagLength),
+		}))
+		return
+	}
+	if !s.retag(r, from, to) {
+		http.NotFound(w, r)
+		return
+	}
+	redirect(w, r, "/tags")
+}
+
+// /tags/{name} removes a tag from every contact
+func (s *Server) deleteTag(w http.ResponseWriter, r *http.Request) {
+	if !s.retag(r, models.NormalizeTag(r.PathValue("name")), "") {
+		http.NotFound(w, r)
+		return
+	}
+	renderString(w, "")
+}
+
+// retag replaces from with to on every contact tagged from, dropping it when
+// to is empty. contacts in the trash are retagged too, so they don't bring
+// the old tag back when restored. it reports whether any contact had the
+// tag.
+func (s *Server) retag(r *http.Request, from, to string) bool {
+	found := false
+	retag := func(before models.Contact, edit func(models.Contact) error) {
+		if !before.HasTag(from) {
+			return
+		}
+		found = true
+		after := before
+		after.Tags = slices.DeleteFunc(slices.Clone(before.Tags), func(tag string) bool { return tag == from })
+		if to != "" {
+			after.Tags = models.NormalizeTags(append(after.Tags, to))
+		}
+		if err := edit(after); err != nil {
+			log.Println(err)
+			return
+		}
+		s.recordChange(r, audit.ActionEdit, before, after)
+		s.recordRevision(r, before, after, 0)
+	}
+	for _, contact := range s.store.AllContacts() {
+		retag(contact, s.store.EditContact)
+	}
+	for _, contact := range s.store.GetTrash() {
+		retag(contact, s.store.EditTrashedContact)
+	}
+	return found
+}
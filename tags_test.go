@@ -0,0 +1,111 @@
+package contactapp
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+func TestTags(t *testing.T) {
+	store := NewinMemoryStore()
+	auditLog := audit.New(&bytes.Buffer{})
+	server := NewContactServer(store, WithAuditLog(auditLog))
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	tagsOf := func(id int) []string {
+		contact, _ := store.GetContact(id)
+		return contact.Tags
+	}
+
+	t.Run("edit form saves normalized tags", func(t *testing.T) {
+		contact, _ := store.GetContact(1)
+		f, _ := url.ParseQuery(contactToForm(contact))
+		f[views.ContactFormTag] = []string{"Customer", " vip ", "customer", ""}
+		req, _ := http.NewRequest(http.MethodPost, "/contacts/1/edit", strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		assertRedirect(t, serve(req), "/contacts/1")
+		if got, want := tagsOf(1), []string{"customer", "vip"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got tags %v, wanted %v", got, want)
+		}
+
+		john, _ := store.GetContact(2)
+		john.Tags = []string{"vendor"}
+		store.EditContact(john)
+	})
+
+	t.Run("list filters by tag and query together", func(t *testing.T) {
+		body := serve(newGetRequest("/contacts?tag=Customer")).Body.String()
+		if !strings.Contains(body, "Jackson") || strings.Contains(body, "JohnDoe") {
+			t.Errorf("expected only the customer in the list")
+		}
+		body = serve(newGetRequest("/contacts?tag=customer&q=John")).Body.String()
+		if strings.Contains(body, "Jackson") || strings.Contains(body, "JohnDoe") {
+			t.Errorf("expected no contact to match both filters")
+		}
+	})
+
+	t.Run("suggestions start with the typed text", func(t *testing.T) {
+		body := serve(newGetRequest("/tags/suggest?tag=V")).Body.String()
+		if !strings.Contains(body, `value="vendor"`) || !strings.Contains(body, `value="vip"`) || strings.Contains(body, "customer") {
+			t.Errorf("got suggestions %s", body)
+		}
+	})
+
+	t.Run("renaming to an existing tag merges them", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "/tags/vendor/rename", strings.NewReader("name=Customer"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		assertRedirect(t, serve(req), "/tags")
+		if got, want := tagsOf(2), []string{"customer"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got tags %v, wanted %v", got, want)
+		}
+		want := []models.TagCount{{Name: "customer", Count: 2}, {Name: "vip", Count: 1}}
+		if got := models.CountTags(store.AllContacts()); !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, wanted %v", got, want)
+		}
+		events := auditLog.Events(audit.Filter{ContactID: 2})
+		if len(events) == 0 || events[0].Changes[0].Field != "tags" {
+			t.Errorf("expected the rename to be in the audit log, got %v", events)
+		}
+	})
+
+	t.Run("deleting a tag removes it from every contact", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodDelete, "/tags/customer", nil)
+		assertCode(t, serve(req).Code, http.StatusOK)
+		if len(tagsOf(1)) != 1 || len(tagsOf(2)) != 0 {
+			t.Errorf("got tags %v and %v", tagsOf(1), tagsOf(2))
+		}
+		req, _ = http.NewRequest(http.MethodDelete, "/tags/customer", nil)
+		assertCode(t, serve(req).Code, http.StatusNotFound)
+	})
+
+	t.Run("trashed contacts are retagged too", func(t *testing.T) {
+		arthur, _ := store.GetContact(3)
+		arthur.Tags = []string{"golf", "lead"}
+		store.EditContact(arthur)
+		store.DeleteContact(3)
+
+		req, _ := http.NewRequest(http.MethodPost, "/tags/lead/rename", strings.NewReader("name=prospect"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		assertRedirect(t, serve(req), "/tags")
+		req, _ = http.NewRequest(http.MethodDelete, "/tags/golf", nil)
+		assertCode(t, serve(req).Code, http.StatusOK)
+
+		store.RestoreContact(3)
+		if got, want := tagsOf(3), []string{"prospect"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("restored contact has tags %v, wanted %v", got, want)
+		}
+	})
+}
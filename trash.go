@@ -0,0 +1,141 @@
+package contactapp
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/views"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+// /contacts/trash
+func (s *Server) getTrash(w http.ResponseWriter, r *http.Request) {
+	render(w, r.Context(), views.Trash(views.TrashViewModel{
+		Contacts:  s.store.GetTrash(),
+		Retention: s.trashRetention,
+	}))
+}
+
+// /contacts/restore restores the contacts of an undo toast
+func (s *Server) restoreBulkContact(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	for _, str := range r.PostForm["selected_id"] {
+		id, err := strconv.Atoi(str)
+		if err != nil || id <= 0 {
+			continue
+		}
+		if err := s.store.RestoreContact(id); err != nil {
+			continue
+		}
+		s.recordRestore(r, id)
+	}
+	redirect(w, r, "/contacts")
+}
+
+// /contacts/{id}/restore
+func (s *Server) restoreContact(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err := s.store.RestoreContact(id); err != nil {
+		switch err {
+		case ErrNotFound:
+			http.NotFound(w, r)
+		case ErrDuplicateEmail:
+			http.Error(w, "can't restore: "+err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	s.recordRestore(r, id)
+	if r.Header.Get("HX-Trigger") == "restore-link" {
+		renderString(w, "")
+		return
+	}
+	redirect(w, r, "/contacts/trash")
+}
+
+func (s *Server) recordRestore(r *http.Request, id int) {
+	if contact, err := s.store.GetContact(id); err == nil {
+		s.recordChange(r, audit.ActionRestore, models.Contact{}, contact)
+	}
+}
+
+// /contacts/{id}/purge
+func (s *Server) purgeContact(w http.ResponseWriter, r *http.Request) {
+	id, err := extractId(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	before, found := s.trashedContact(id)
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	if err := s.store.PurgeContact(id); err != nil {
+		switch err {
+		case ErrNotFound:
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+	s.relations.DeleteContactRelationships(id)
+	s.groups.RemoveContactFromGroups(id)
+	s.interactions.DeleteContactInteractions(id)
+	s.reminders.DeleteContactReminders(id)
+	s.recordChange(r, audit.ActionPurge, before, models.Contact{})
+	if r.Header.Get("HX-Trigger") == "purge-link" {
+		renderString(w, "")
+		return
+	}
+	redirect(w, r, "/contacts/trash")
+}
+
+func (s *Server) trashedContact(id int) (models.Contact, bool) {
+	for _, contact := range s.store.GetTrash() {
+		if contact.ID == id {
+			return contact, true
+		}
+	}
+	return models.Contact{}, false
+}
+
+// PurgeExpiredTrash permanently removes contacts that have been in the trash
+// longer than the retention period, along with their relationships, group
+// memberships, timelines and reminders
+func (s *Server) PurgeExpiredTrash() {
+	for _, contact := range s.store.PurgeDeletedBefore(time.Now().Add(-s.trashRetention)) {
+		s.relations.DeleteContactRelationships(contact.ID)
+		s.groups.RemoveContactFromGroups(contact.ID)
+		s.interactions.DeleteContactInteractions(contact.ID)
+		s.reminders.DeleteContactReminders(contact.ID)
+		s.recordEvent(systemActor, "", audit.ActionPurge, contact, models.Contact{})
+	}
+}
+
+// RunTrashPurger purges expired trash every interval until ctx is done
+func (s *Server) RunTrashPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			log.Println("purging expired trash")
+			s.PurgeExpiredTrash()
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package contactapp
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/audit"
+	"github.com/rezbow/contact-app/models"
+)
+
+func TestTrash(t *testing.T) {
+	store := NewinMemoryStore()
+	auditLog := audit.New(&bytes.Buffer{})
+	groups := NewInMemoryGroupStore()
+	team, _ := groups.AddGroup(models.Group{Name: "Team", Members: []int{1, 2, 3}})
+	server := NewContactServer(store, WithAuditLog(auditLog), WithGroupStore(groups), WithTrashRetention(24*time.Hour))
+	session := signedIn(server)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		server.ServeHTTP(res, withSession(req, session))
+		return res
+	}
+	request := func(method, path string) *http.Request {
+		req, _ := http.NewRequest(method, path, nil)
+		return req
+	}
+	restoreRequest := func(ids ...int) *http.Request {
+		f := url.Values{}
+		for _, id := range ids {
+			f.Add("selected_id", fmt.Sprint(id))
+		}
+		req, _ := http.NewRequest(http.MethodPost, "/contacts/restore", strings.NewReader(f.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+
+	t.Run("inline delete moves contact to trash and returns an undo toast", func(t *testing.T) {
+		req := request(http.MethodDelete, "/contacts/1")
+		req.Header.Set("HX-Trigger", "delete-link")
+		res := serve(req)
+		assertCode(t, res.Code, http.StatusOK)
+		body := res.Body.String()
+		if !strings.Contains(body, `hx-swap-oob="true"`) || !strings.Contains(body, `value="1"`) {
+			t.Errorf("expected an out of band undo toast for contact 1, got %s", body)
+		}
+		if _, err := store.GetContact(1); err == nil {
+			t.Errorf("deleted contact is still visible")
+		}
+		if trash := store.GetTrash(); len(trash) != 1 || trash[0].ID != 1 {
+			t.Errorf("got trash %v, wanted contact 1", trash)
+		}
+	})
+
+	t.Run("undo restores the contact", func(t *testing.T) {
+		res := serve(restoreRequest(1))
+		assertRedirect(t, res, "/contacts")
+		if _, err := store.GetContact(1); err != nil {
+			t.Errorf("contact wasn't restored: %v", err)
+		}
+		if got := auditLog.Events(audit.Filter{})[0].Action; got != audit.ActionRestore {
+			t.Errorf("got %q as latest audit action, wanted %q", got, audit.ActionRestore)
+		}
+	})
+
+	t.Run("bulk delete shows undo toast for all contacts", func(t *testing.T) {
+		res := serve(request(http.MethodDelete, "/contacts?selected_id=1&selected_id=2"))
+		body := res.Body.String()
+		if !strings.Contains(body, "2 contacts moved to trash.") {
+			t.Errorf("expected undo toast for both contacts")
+		}
+		serve(restoreRequest(1, 2))
+		if len(store.GetTrash()) != 0 {
+			t.Errorf("expected trash to be empty after undo")
+		}
+	})
+
+	t.Run("delete with redirect shows the toast on the next page once", func(t *testing.T) {
+		res := serve(request(http.MethodDelete, "/contacts/2"))
+		assertRedirect(t, res, "/contacts")
+		res = serve(newGetRequest("/contacts"))
+		if !strings.Contains(res.Body.String(), "Contact moved to trash.") {
+			t.Errorf("expected undo toast after redirect")
+		}
+		res = serve(newGetRequest("/contacts"))
+		if strings.Contains(res.Body.String(), "Contact moved to trash.") {
+			t.Errorf("undo toast should only be shown once")
+		}
+	})
+
+	t.Run("trash page lists deleted contacts", func(t *testing.T) {
+		res := serve(newGetRequest("/contacts/trash"))
+		assertCode(t, res.Code, http.StatusOK)
+		if !strings.Contains(res.Body.String(), "/contacts/2/restore") {
+			t.Errorf("expected contact 2 in trash page")
+		}
+	})
+
+	t.Run("restore is refused when the email was taken meanwhile", func(t *testing.T) {
+		trashed := store.GetTrash()[0]
+		other, _ := store.GetContact(3)
+		other.Emails = trashed.Emails
+		store.EditContact(other)
+
+		res := serve(request(http.MethodPost, "/contacts/2/restore"))
+		assertCode(t, res.Code, http.StatusConflict)
+		if len(store.GetTrash()) != 1 {
+			t.Errorf("contact left the trash despite conflict")
+		}
+	})
+
+	t.Run("purge only works on trashed contacts", func(t *testing.T) {
+		assertCode(t, serve(request(http.MethodDelete, "/contacts/3/purge")).Code, http.StatusNotFound)
+
+		req := request(http.MethodDelete, "/contacts/2/purge")
+		req.Header.Set("HX-Trigger", "purge-link")
+		assertCode(t, serve(req).Code, http.StatusOK)
+		if len(store.GetTrash()) != 0 {
+			t.Errorf("contact wasn't purged")
+		}
+		assertCode(t, serve(request(http.MethodPost, "/contacts/2/restore")).Code, http.StatusNotFound)
+		event := auditLog.Events(audit.Filter{})[0]
+		if event.Action != audit.ActionPurge || event.ContactID != 2 {
+			t.Errorf("got %+v, wanted purge of contact 2", event)
+		}
+		if group, _ := groups.GetGroup(team.ID); group.HasMember(2) {
+			t.Errorf("purged contact is still a member of %q", group.Name)
+		}
+	})
+
+	t.Run("expired trash is purged automatically", func(t *testing.T) {
+		serve(request(http.MethodDelete, "/contacts/1"))
+		serve(request(http.MethodDelete, "/contacts/3"))
+		for idx := range store.contacts {
+			if store.contacts[idx].ID == 1 {
+				store.contacts[idx].DeletedAt = time.Now().Add(-48 * time.Hour)
+			}
+		}
+		server.PurgeExpiredTrash()
+		trash := store.GetTrash()
+		if len(trash) != 1 || trash[0].ID != 3 {
+			t.Errorf("got trash %v, wanted only the recently deleted contact 3", trash)
+		}
+		event := auditLog.Events(audit.Filter{})[0]
+		if event.Actor != systemActor || event.ContactID != 1 {
+			t.Errorf("got %+v, wanted system purge of contact 1", event)
+		}
+		if group, _ := groups.GetGroup(team.ID); !slices.Equal(group.Members, []int{3}) {
+			t.Errorf("got members %v, wanted the trashed contact 3 only", group.Members)
+		}
+	})
+}
@@ -1,9 +1,16 @@
 package views
 
 import (
+	"fmt"
+	"maps"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/rezbow/contact-app/models"
+	"github.com/rezbow/contact-app/phone"
 )
 
 type FormErrors map[string]string
@@ -16,15 +23,45 @@ func (e FormErrors) Get(field string) string {
 	return e[field]
 }
 
+// error key of one row of a multi-valued field, e.g. "email.1"
+func rowKey(field string, idx int) string {
+	return fmt.Sprintf("%s.%d", field, idx)
+}
+
 type ContactForm struct {
-	ID          int
-	FirstName   string
-	LastName    string
-	PhoneNumber string
-	Email       string
-	Errors      FormErrors
+	ID        int
+	FirstName string
+	LastName  string
+	Phones    []models.Phone
+	Emails    []models.Email
+	Addresses []models.Address
+	Company   string
+	JobTitle  string
+	// as typed, yyyy-mm-dd
+	Birthday string
+	Website  string
+	Notes    string
+	// region of the phone numbers entered without a country code, see
+	// package phone
+	Region string
+	// custom fields of the address book and their values by key
+	Fields []models.FieldDefinition
+	Custom map[string]string
+	Tags   []string
+	// id of the current photo, uploaded separately
+	Photo string
+	// the version of the contact being edited, see models.Contact
+	Version int
+	// relationships are changed separately too, on the edit page
+	Related      []Related
+	Relationship *RelationshipForm
+	Errors       FormErrors
 }
 
+const maxNotesLength = 10000
+
+var minBirthday = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
 func (c *ContactForm) Valid() bool {
 	if c.FirstName == "" {
 		c.Errors.Set(ContactFormFirstName, "must not be empty")
@@ -32,43 +69,245 @@ func (c *ContactForm) Valid() bool {
 	if c.LastName == "" {
 		c.Errors.Set(ContactFormLastName, "must not be empty")
 	}
-	if c.PhoneNumber == "" {
+	if len(c.Phones) == 0 {
 		c.Errors.Set(ContactFormPhone, "must not be empty")
 	}
-	if c.Email == "" {
+	if len(c.Emails) == 0 {
 		c.Errors.Set(ContactFormEmail, "must not be empty")
 	}
+	for idx, p := range c.Phones {
+		n, err := phone.Parse(p.Number, c.Region)
+		if err != nil {
+			c.Errors.Set(rowKey(ContactFormPhone, idx), err.Error())
+			continue
+		}
+		c.Phones[idx].E164 = n.E164()
+	}
+	seen := make(map[string]bool)
+	for idx, email := range c.Emails {
+		address := strings.ToLower(email.Address)
+		if seen[address] {
+			c.Errors.Set(rowKey(ContactFormEmail, idx), "listed twice")
+		}
+		seen[address] = true
+	}
+	if c.Birthday != "" {
+		birthday, err := time.Parse(time.DateOnly, c.Birthday)
+		switch {
+		case err != nil:
+			c.Errors.Set(ContactFormBirthday, "must be a date like 1990-12-31")
+		case birthday.After(time.Now()):
+			c.Errors.Set(ContactFormBirthday, "can't be in the future")
+		case birthday.Before(minBirthday):
+			c.Errors.Set(ContactFormBirthday, "must be after 1900")
+		}
+	}
+	if c.Website != "" {
+		u, err := url.Parse(c.Website)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			c.Errors.Set(ContactFormWebsite, "must be a http or https address")
+		}
+	}
+	if len(c.Notes) > maxNotesLength {
+		c.Errors.Set(ContactFormNotes, fmt.Sprintf("must be at most %d characters", maxNotesLength))
+	}
+	for _, tag := range c.Tags {
+		if len(tag) > models.MaxTagLength {
+			c.Errors.Set(ContactFormTag, fmt.Sprintf("tags must be at most %d characters", models.MaxTagLength))
+		}
+	}
+	c.validCustom()
 	return len(c.Errors) == 0
 }
 
+// validCustom checks the custom values against Fields, leaving them in
+// canonical form. values of fields not in the schema are dropped.
+func (c *ContactForm) validCustom() {
+	custom := make(map[string]string)
+	for _, field := range c.Fields {
+		value, err := field.Normalize(c.Custom[field.Key])
+		if err != nil {
+			c.Errors.Set(CustomFieldName(field.Key), err.Error())
+			value = c.Custom[field.Key]
+		}
+		if value != "" {
+			custom[field.Key] = value
+		}
+	}
+	c.Custom = custom
+}
+
+// ToContact expects a valid form
 func (c *ContactForm) ToContact() *models.Contact {
+	birthday, _ := time.Parse(time.DateOnly, c.Birthday)
 	return &models.Contact{
-		ID:          c.ID,
-		FirstName:   c.FirstName,
-		LastName:    c.LastName,
-		Email:       c.Email,
-		PhoneNumber: c.PhoneNumber,
+		ID:        c.ID,
+		FirstName: c.FirstName,
+		LastName:  c.LastName,
+		Phones:    c.Phones,
+		Emails:    c.Emails,
+		Addresses: c.Addresses,
+		Company:   c.Company,
+		JobTitle:  c.JobTitle,
+		Birthday:  birthday,
+		Website:   c.Website,
+		Notes:     c.Notes,
+		Custom:    customValues(c.Custom),
+		Tags:      c.Tags,
+		Version:   c.Version,
+	}
+}
+
+// nil rather than empty, so contacts without custom values compare equal
+func customValues(custom map[string]string) map[string]string {
+	values := make(map[string]string)
+	for key, value := range custom {
+		if value != "" {
+			values[key] = value
+		}
 	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
 }
 
 func ContactFormFromContact(contact *models.Contact) *ContactForm {
+	var birthday string
+	if contact.HasBirthday() {
+		birthday = contact.Birthday.Format(time.DateOnly)
+	}
 	return &ContactForm{
-		ID:          contact.ID,
-		FirstName:   contact.FirstName,
-		LastName:    contact.LastName,
-		PhoneNumber: contact.PhoneNumber,
-		Email:       contact.Email,
-		Errors:      make(FormErrors),
+		ID:        contact.ID,
+		FirstName: contact.FirstName,
+		LastName:  contact.LastName,
+		Phones:    contact.Phones,
+		Emails:    contact.Emails,
+		Addresses: contact.Addresses,
+		Company:   contact.Company,
+		JobTitle:  contact.JobTitle,
+		Birthday:  birthday,
+		Website:   contact.Website,
+		Notes:     contact.Notes,
+		Custom:    maps.Clone(contact.Custom),
+		Tags:      contact.Tags,
+		Photo:     contact.Photo,
+		Version:   contact.Version,
+		Errors:    make(FormErrors),
 	}
 }
 
 func ContactFormFromRequest(r *http.Request) *ContactForm {
 	r.ParseForm()
+	version, _ := strconv.Atoi(r.PostForm.Get(ContactFormVersion))
 	return &ContactForm{
-		FirstName:   r.PostForm.Get(ContactFormFirstName),
-		LastName:    r.PostForm.Get(ContactFormLastName),
-		Email:       r.PostForm.Get(ContactFormEmail),
-		PhoneNumber: r.PostForm.Get(ContactFormPhone),
-		Errors:      make(FormErrors),
+		FirstName: r.PostForm.Get(ContactFormFirstName),
+		LastName:  r.PostForm.Get(ContactFormLastName),
+		Phones:    phonesFromForm(r),
+		Emails:    emailsFromForm(r),
+		Addresses: addressesFromForm(r),
+		Company:   strings.TrimSpace(r.PostForm.Get(ContactFormCompany)),
+		JobTitle:  strings.TrimSpace(r.PostForm.Get(ContactFormJobTitle)),
+		Birthday:  strings.TrimSpace(r.PostForm.Get(ContactFormBirthday)),
+		Website:   normalizeWebsite(r.PostForm.Get(ContactFormWebsite)),
+		Notes:     strings.TrimSpace(r.PostForm.Get(ContactFormNotes)),
+		Custom:    customFromForm(r),
+		Tags:      models.NormalizeTags(r.PostForm[ContactFormTag]),
+		Version:   version,
+		Errors:    make(FormErrors),
+	}
+}
+
+// input name of a custom field, e.g. "custom.slack_handle"
+func CustomFieldName(key string) string {
+	return customFieldPrefix + key
+}
+
+// every submitted custom value, Valid sorts out which ones are in the schema
+func customFromForm(r *http.Request) map[string]string {
+	custom := make(map[string]string)
+	for name := range r.PostForm {
+		if key, ok := strings.CutPrefix(name, customFieldPrefix); ok {
+			custom[key] = strings.TrimSpace(r.PostForm.Get(name))
+		}
+	}
+	return custom
+}
+
+// people type "example.com", we store "https://example.com"
+func normalizeWebsite(website string) string {
+	website = strings.TrimSpace(website)
+	if website != "" && !strings.Contains(website, "://") {
+		website = "https://" + website
+	}
+	return website
+}
+
+// value of the idx-th input named field, rows with a missing input get ""
+func rowValue(r *http.Request, field string, idx int) string {
+	values := r.PostForm[field]
+	if idx < len(values) {
+		return strings.TrimSpace(values[idx])
+	}
+	return ""
+}
+
+// a missing or unknown label falls back to the first one
+func rowLabel(r *http.Request, field string, idx int, labels []string) string {
+	label := rowValue(r, field, idx)
+	for _, l := range labels {
+		if l == label {
+			return label
+		}
+	}
+	return labels[0]
+}
+
+func phonesFromForm(r *http.Request) []models.Phone {
+	var phones []models.Phone
+	for idx := range r.PostForm[ContactFormPhone] {
+		number := rowValue(r, ContactFormPhone, idx)
+		if number == "" {
+			continue
+		}
+		phones = append(phones, models.Phone{
+			Label:  rowLabel(r, ContactFormPhoneLabel, idx, models.PhoneLabels),
+			Number: number,
+		})
+	}
+	return phones
+}
+
+func emailsFromForm(r *http.Request) []models.Email {
+	var emails []models.Email
+	for idx := range r.PostForm[ContactFormEmail] {
+		address := rowValue(r, ContactFormEmail, idx)
+		if address == "" {
+			continue
+		}
+		emails = append(emails, models.Email{
+			Label:   rowLabel(r, ContactFormEmailLabel, idx, models.EmailLabels),
+			Address: address,
+		})
+	}
+	return emails
+}
+
+func addressesFromForm(r *http.Request) []models.Address {
+	var addresses []models.Address
+	for idx := range r.PostForm[ContactFormAddressStreet] {
+		address := models.Address{
+			Label:      rowLabel(r, ContactFormAddressLabel, idx, models.AddressLabels),
+			Street:     rowValue(r, ContactFormAddressStreet, idx),
+			City:       rowValue(r, ContactFormAddressCity, idx),
+			Region:     rowValue(r, ContactFormAddressRegion, idx),
+			PostalCode: rowValue(r, ContactFormAddressPostalCode, idx),
+			Country:    rowValue(r, ContactFormAddressCountry, idx),
+		}
+		if address.Empty() {
+			continue
+		}
+		addresses = append(addresses, address)
 	}
+	return addresses
 }
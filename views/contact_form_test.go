@@ -0,0 +1,214 @@
+package views
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+func postForm(f url.Values) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "/contacts/new", strings.NewReader(f.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestContactForm(t *testing.T) {
+	t.Run("parses labelled rows and skips blank ones", func(t *testing.T) {
+		f := url.Values{}
+		f.Set(ContactFormFirstName, "Reza")
+		f.Set(ContactFormLastName, "B")
+		f[ContactFormPhoneLabel] = []string{"work", "home", "bogus"}
+		f[ContactFormPhone] = []string{"+1 415 555 0111", " ", "+1 415 555 0333"}
+		f[ContactFormEmail] = []string{"a@b.com", "c@d.com"}
+		f[ContactFormEmailLabel] = []string{"work"}
+		f[ContactFormAddressLabel] = []string{"home", "work"}
+		f[ContactFormAddressStreet] = []string{"1 Main St", ""}
+		f[ContactFormAddressCity] = []string{"Springfield", ""}
+		f[ContactFormAddressRegion] = []string{"", ""}
+		f[ContactFormAddressPostalCode] = []string{"12345", ""}
+		f[ContactFormAddressCountry] = []string{"US", ""}
+
+		form := ContactFormFromRequest(postForm(f))
+		wantPhones := []models.Phone{{Label: "work", Number: "+1 415 555 0111"}, {Label: "mobile", Number: "+1 415 555 0333"}}
+		wantEmails := []models.Email{{Label: "work", Address: "a@b.com"}, {Label: "home", Address: "c@d.com"}}
+		wantAddresses := []models.Address{{Label: "home", Street: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US"}}
+		if !reflect.DeepEqual(form.Phones, wantPhones) {
+			t.Errorf("got phones %v, wanted %v", form.Phones, wantPhones)
+		}
+		if !reflect.DeepEqual(form.Emails, wantEmails) {
+			t.Errorf("got emails %v, wanted %v", form.Emails, wantEmails)
+		}
+		if !reflect.DeepEqual(form.Addresses, wantAddresses) {
+			t.Errorf("got addresses %v, wanted %v", form.Addresses, wantAddresses)
+		}
+		if !form.Valid() {
+			t.Errorf("expected form to be valid, got errors %v", form.Errors)
+		}
+	})
+
+	t.Run("same email twice is invalid", func(t *testing.T) {
+		f := url.Values{}
+		f.Set(ContactFormFirstName, "Reza")
+		f.Set(ContactFormLastName, "B")
+		f.Set(ContactFormPhone, "111")
+		f[ContactFormEmail] = []string{"a@b.com", "A@B.com"}
+
+		form := ContactFormFromRequest(postForm(f))
+		if form.Valid() {
+			t.Fatalf("expected duplicate emails to be invalid")
+		}
+		if form.Errors.Get(rowKey(ContactFormEmail, 1)) == "" {
+			t.Errorf("expected error on second email row, got %v", form.Errors)
+		}
+	})
+
+	t.Run("phone numbers must parse and are kept in E.164", func(t *testing.T) {
+		f := url.Values{}
+		f.Set(ContactFormFirstName, "Reza")
+		f.Set(ContactFormLastName, "B")
+		f[ContactFormPhone] = []string{"(415) 555-0123", "213214", "+39 06 1234 5678"}
+		f.Set(ContactFormEmail, "a@b.com")
+
+		form := ContactFormFromRequest(postForm(f))
+		form.Region = "US"
+		if form.Valid() {
+			t.Fatalf("expected a phone number that doesn't parse to be invalid")
+		}
+		if form.Errors.Get(rowKey(ContactFormPhone, 0)) != "" || form.Errors.Get(rowKey(ContactFormPhone, 1)) == "" ||
+			form.Errors.Get(rowKey(ContactFormPhone, 2)) != "" {
+			t.Errorf("expected an error on the second phone row only, got %v", form.Errors)
+		}
+		// countries phone has no plan for are checked as E.164 alone
+		if got := form.Phones[2].E164; got != "+390612345678" {
+			t.Errorf("got %q for an italian number", got)
+		}
+		if got := form.Phones[0]; got.Number != "(415) 555-0123" || got.E164 != "+14155550123" {
+			t.Errorf("got phone %+v", got)
+		}
+	})
+
+	t.Run("at least one phone and email are required", func(t *testing.T) {
+		f := url.Values{}
+		f.Set(ContactFormFirstName, "Reza")
+		f.Set(ContactFormLastName, "B")
+		f.Set(ContactFormPhone, "")
+		form := ContactFormFromRequest(postForm(f))
+		if form.Valid() {
+			t.Fatalf("expected form without phone and email to be invalid")
+		}
+		if form.Errors.Get(ContactFormPhone) == "" || form.Errors.Get(ContactFormEmail) == "" {
+			t.Errorf("got errors %v", form.Errors)
+		}
+	})
+
+	t.Run("birthday must be a past date after 1900", func(t *testing.T) {
+		cases := map[string]bool{
+			"1990-05-17": true,
+			"17/05/1990": false,
+			"1990-02-30": false,
+			"1899-12-31": false,
+			time.Now().AddDate(0, 0, 2).Format(time.DateOnly): false,
+		}
+		for birthday, valid := range cases {
+			form := validForm()
+			form.Birthday = birthday
+			if form.Valid() != valid {
+				t.Errorf("birthday %q: got valid %v, wanted %v (%v)", birthday, !valid, valid, form.Errors)
+			}
+		}
+	})
+
+	t.Run("website gets a scheme and must be http", func(t *testing.T) {
+		f := url.Values{}
+		f.Set(ContactFormWebsite, " example.com/me ")
+		if got := ContactFormFromRequest(postForm(f)).Website; got != "https://example.com/me" {
+			t.Errorf("got website %q, wanted %q", got, "https://example.com/me")
+		}
+		for website, valid := range map[string]bool{
+			"https://example.com":  true,
+			"http://example.com":   true,
+			"javascript://alert()": false,
+			"ftp://example.com":    false,
+			"https://":             false,
+		} {
+			form := validForm()
+			form.Website = website
+			if form.Valid() != valid {
+				t.Errorf("website %q: got valid %v, wanted %v", website, !valid, valid)
+			}
+		}
+	})
+
+	t.Run("notes have a length limit", func(t *testing.T) {
+		form := validForm()
+		form.Notes = strings.Repeat("x", maxNotesLength+1)
+		if form.Valid() {
+			t.Errorf("expected overly long notes to be invalid")
+		}
+	})
+}
+
+func TestCustomFieldValues(t *testing.T) {
+	one := 1.0
+	fields := []models.FieldDefinition{
+		{Key: "size", Label: "Size", Type: models.FieldNumber, Min: &one},
+		{Key: "since", Label: "Since", Type: models.FieldDate},
+		{Key: "tier", Label: "Tier", Type: models.FieldEnum, Options: []string{"gold", "silver"}},
+		{Key: "blog", Label: "Blog", Type: models.FieldURL},
+		{Key: "vip", Label: "VIP", Type: models.FieldBoolean},
+		{Key: "code", Label: "Code", Type: models.FieldText, Required: true, Pattern: "[A-Z]{3}", MaxLength: 3},
+	}
+
+	t.Run("valid values are kept in canonical form", func(t *testing.T) {
+		form := validForm()
+		form.Fields = fields
+		form.Custom = map[string]string{"size": "05", "since": "2020-01-02", "tier": "gold",
+			"blog": "https://blog.example", "vip": "on", "code": "ABC", "other": "dropped"}
+		if !form.Valid() {
+			t.Fatalf("expected form to be valid, got errors %v", form.Errors)
+		}
+		want := map[string]string{"size": "5", "since": "2020-01-02", "tier": "gold",
+			"blog": "https://blog.example", "vip": "true", "code": "ABC"}
+		if got := form.ToContact().Custom; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, wanted %v", got, want)
+		}
+	})
+
+	t.Run("invalid values get an error each", func(t *testing.T) {
+		form := validForm()
+		form.Fields = fields
+		form.Custom = map[string]string{"size": "0", "since": "yesterday", "tier": "bronze",
+			"blog": "javascript:alert(1)", "code": "abcd"}
+		if form.Valid() {
+			t.Fatalf("expected form to be invalid")
+		}
+		for _, key := range []string{"size", "since", "tier", "blog", "code"} {
+			if form.Errors.Get(CustomFieldName(key)) == "" {
+				t.Errorf("expected an error for %s", key)
+			}
+		}
+	})
+
+	t.Run("required fields must be filled", func(t *testing.T) {
+		form := validForm()
+		form.Fields = fields
+		if form.Valid() || form.Errors.Get(CustomFieldName("code")) == "" {
+			t.Errorf("expected missing required field to be invalid, got %v", form.Errors)
+		}
+	})
+}
+
+func validForm() *ContactForm {
+	return &ContactForm{
+		FirstName: "Reza",
+		LastName:  "B",
+		Phones:    []models.Phone{{Label: "mobile", Number: "+1 415 555 0100"}},
+		Emails:    []models.Email{{Label: "home", Address: "a@b.com"}},
+		Errors:    make(FormErrors),
+	}
+}
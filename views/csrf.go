@@ -0,0 +1,29 @@
+package views
+
+import (
+	"context"
+	"encoding/json"
+)
+
+const (
+	CSRFFieldName  = "csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+type csrfTokenKey struct{}
+
+// attaches the session's csrf token to ctx so templates can embed it
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, csrfTokenKey{}, token)
+}
+
+func CSRFToken(ctx context.Context) string {
+	token, _ := ctx.Value(csrfTokenKey{}).(string)
+	return token
+}
+
+// value for hx-headers so every htmx request carries the token
+func csrfHeaders(ctx context.Context) string {
+	headers, _ := json.Marshal(map[string]string{CSRFHeaderName: CSRFToken(ctx)})
+	return string(headers)
+}
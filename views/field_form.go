@@ -0,0 +1,102 @@
+package views
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+const (
+	FieldFormKey       = "key"
+	FieldFormLabel     = "label"
+	FieldFormType      = "type"
+	FieldFormRequired  = "required"
+	FieldFormOptions   = "options"
+	FieldFormMin       = "min"
+	FieldFormMax       = "max"
+	FieldFormPattern   = "pattern"
+	FieldFormMaxLength = "max_length"
+	// errors of the definition as a whole
+	FieldFormDefinition = "definition"
+)
+
+// FieldForm defines or redefines a custom field, numbers are kept as typed
+type FieldForm struct {
+	Key       string
+	Label     string
+	Type      string
+	Required  bool
+	Options   string
+	Min       string
+	Max       string
+	Pattern   string
+	MaxLength string
+	Errors    FormErrors
+	field     models.FieldDefinition
+}
+
+func FieldFormFromRequest(r *http.Request) *FieldForm {
+	r.ParseForm()
+	return &FieldForm{
+		Key:       strings.TrimSpace(r.PostForm.Get(FieldFormKey)),
+		Label:     strings.TrimSpace(r.PostForm.Get(FieldFormLabel)),
+		Type:      r.PostForm.Get(FieldFormType),
+		Required:  r.PostForm.Get(FieldFormRequired) != "",
+		Options:   strings.TrimSpace(r.PostForm.Get(FieldFormOptions)),
+		Min:       strings.TrimSpace(r.PostForm.Get(FieldFormMin)),
+		Max:       strings.TrimSpace(r.PostForm.Get(FieldFormMax)),
+		Pattern:   strings.TrimSpace(r.PostForm.Get(FieldFormPattern)),
+		MaxLength: strings.TrimSpace(r.PostForm.Get(FieldFormMaxLength)),
+		Errors:    make(FormErrors),
+	}
+}
+
+func (f *FieldForm) Valid() bool {
+	f.field = models.FieldDefinition{
+		Key:      f.Key,
+		Label:    f.Label,
+		Type:     models.FieldType(f.Type),
+		Required: f.Required,
+		Pattern:  f.Pattern,
+	}
+	// comma separated
+	for _, option := range strings.Split(f.Options, ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			f.field.Options = append(f.field.Options, option)
+		}
+	}
+	f.field.Min = f.parseBound(FieldFormMin, f.Min)
+	f.field.Max = f.parseBound(FieldFormMax, f.Max)
+	if f.MaxLength != "" {
+		n, err := strconv.Atoi(f.MaxLength)
+		if err != nil || n < 0 {
+			f.Errors.Set(FieldFormMaxLength, "must be a positive whole number")
+		}
+		f.field.MaxLength = n
+	}
+	if len(f.Errors) == 0 {
+		if err := f.field.Check(); err != nil {
+			f.Errors.Set(FieldFormDefinition, err.Error())
+		}
+	}
+	return len(f.Errors) == 0
+}
+
+func (f *FieldForm) parseBound(name, value string) *float64 {
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		f.Errors.Set(name, "must be a number")
+		return nil
+	}
+	return &n
+}
+
+// ToField expects a valid form
+func (f *FieldForm) ToField() models.FieldDefinition {
+	return f.field
+}
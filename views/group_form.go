@@ -0,0 +1,53 @@
+package views
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+const (
+	GroupFormName        = "name"
+	GroupFormDescription = "description"
+	// the group of the bulk "add selected to group" action
+	GroupFormGroup = "group"
+)
+
+const maxGroupNameLength = 100
+
+type GroupForm struct {
+	ID          int
+	Name        string
+	Description string
+	Errors      FormErrors
+}
+
+func GroupFormFromRequest(r *http.Request) *GroupForm {
+	r.ParseForm()
+	return &GroupForm{
+		Name:        strings.TrimSpace(r.PostForm.Get(GroupFormName)),
+		Description: strings.TrimSpace(r.PostForm.Get(GroupFormDescription)),
+		Errors:      make(FormErrors),
+	}
+}
+
+func GroupFormFromGroup(group models.Group) *GroupForm {
+	return &GroupForm{
+		ID:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		Errors:      make(FormErrors),
+	}
+}
+
+func (f *GroupForm) Valid() bool {
+	if f.Name == "" {
+		f.Errors.Set(GroupFormName, "must not be empty")
+	}
+	if len(f.Name) > maxGroupNameLength {
+		f.Errors.Set(GroupFormName, fmt.Sprintf("must be at most %d characters", maxGroupNameLength))
+	}
+	return len(f.Errors) == 0
+}
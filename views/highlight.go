@@ -0,0 +1,52 @@
+package views
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+// highlight is a piece of text, marked when it matched a search
+type highlight struct {
+	Text  string
+	Match bool
+}
+
+// highlights splits text into words and what's between them, marking the
+// words that a search term starts, see models.MatchesQuery
+func highlights(text string, terms []string) []highlight {
+	if len(terms) == 0 {
+		return []highlight{{Text: text}}
+	}
+	var parts []highlight
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for text != "" {
+		word := strings.IndexFunc(text, func(r rune) bool { return !isWord(r) })
+		if word == -1 {
+			word = len(text)
+		}
+		if word > 0 {
+			parts = append(parts, highlight{Text: text[:word], Match: matchesTerm(text[:word], terms)})
+			text = text[word:]
+			continue
+		}
+		gap := strings.IndexFunc(text, isWord)
+		if gap == -1 {
+			gap = len(text)
+		}
+		parts = append(parts, highlight{Text: text[:gap]})
+		text = text[gap:]
+	}
+	return parts
+}
+
+func matchesTerm(word string, terms []string) bool {
+	folded := models.Fold(word)
+	for _, term := range terms {
+		if strings.HasPrefix(folded, term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package views
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHighlights(t *testing.T) {
+	got := highlights("Chloé Dupré-Marchand", []string{"chloe", "march"})
+	want := []highlight{
+		{Text: "Chloé", Match: true},
+		{Text: " "},
+		{Text: "Dupré"},
+		{Text: "-"},
+		{Text: "Marchand", Match: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, wanted %+v", got, want)
+	}
+	if got := highlights("a, b", nil); len(got) != 1 || got[0].Text != "a, b" || got[0].Match {
+		t.Errorf("expected nothing highlighted without terms, got %+v", got)
+	}
+}
@@ -0,0 +1,85 @@
+package views
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+const (
+	InteractionFormType     = "interaction_type"
+	InteractionFormTime     = "time"
+	InteractionFormSummary  = "summary"
+	InteractionFormFollowUp = "follow_up"
+)
+
+// layout of <input type="datetime-local">
+const dateTimeLocal = "2006-01-02T15:04"
+
+const maxSummaryLength = 1000
+
+type InteractionForm struct {
+	Type     string
+	Time     string
+	Summary  string
+	FollowUp string
+	Errors   FormErrors
+}
+
+// NewInteractionForm is an empty form logging a call that happened now
+func NewInteractionForm() *InteractionForm {
+	return &InteractionForm{
+		Type:   models.InteractionTypes[0].Key,
+		Time:   time.Now().Format(dateTimeLocal),
+		Errors: make(FormErrors),
+	}
+}
+
+func InteractionFormFromRequest(r *http.Request) *InteractionForm {
+	r.ParseForm()
+	return &InteractionForm{
+		Type:     r.PostForm.Get(InteractionFormType),
+		Time:     r.PostForm.Get(InteractionFormTime),
+		Summary:  strings.TrimSpace(r.PostForm.Get(InteractionFormSummary)),
+		FollowUp: r.PostForm.Get(InteractionFormFollowUp),
+		Errors:   make(FormErrors),
+	}
+}
+
+func (f *InteractionForm) Valid() bool {
+	if _, ok := models.LookupInteractionType(f.Type); !ok {
+		f.Errors.Set(InteractionFormType, "unknown type")
+	}
+	if t, err := time.ParseInLocation(dateTimeLocal, f.Time, time.Local); err != nil {
+		f.Errors.Set(InteractionFormTime, "must be a date and time")
+	} else if t.After(time.Now()) {
+		f.Errors.Set(InteractionFormTime, "can't be in the future")
+	}
+	if f.Summary == "" {
+		f.Errors.Set(InteractionFormSummary, "must not be empty")
+	}
+	if len(f.Summary) > maxSummaryLength {
+		f.Errors.Set(InteractionFormSummary, fmt.Sprintf("must be at most %d characters", maxSummaryLength))
+	}
+	if f.FollowUp != "" {
+		if _, err := time.Parse(time.DateOnly, f.FollowUp); err != nil {
+			f.Errors.Set(InteractionFormFollowUp, "must be a date like 2024-12-31")
+		}
+	}
+	return len(f.Errors) == 0
+}
+
+// ToInteraction is only meaningful on a valid form
+func (f *InteractionForm) ToInteraction() models.Interaction {
+	t, _ := time.ParseInLocation(dateTimeLocal, f.Time, time.Local)
+	followUp, _ := time.Parse(time.DateOnly, f.FollowUp)
+	return models.Interaction{
+		Type:     f.Type,
+		Time:     t,
+		Summary:  f.Summary,
+		FollowUp: followUp,
+	}
+}
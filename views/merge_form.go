@@ -0,0 +1,115 @@
+package views
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+const (
+	// the two contacts being merged
+	MergeFormA = "a"
+	MergeFormB = "b"
+	// their versions when the form was shown
+	MergeFormVersionA = "a_version"
+	MergeFormVersionB = "b_version"
+	// the contact the other is merged into
+	MergeFormKeep = "keep"
+	// prefix of the field choices, e.g. pick.first_name, whose value is the
+	// contact the field is taken from
+	MergeFormPick = "pick."
+)
+
+type MergeForm struct {
+	A, B               int
+	VersionA, VersionB int
+	Keep               int
+	// the contact each field of models.MergeChoices is taken from
+	Picks  map[string]int
+	Errors FormErrors
+}
+
+// NewMergeForm keeps the older contact and its fields, except those it has
+// no value for
+func NewMergeForm(a, b models.Contact) *MergeForm {
+	form := &MergeForm{
+		A:        a.ID,
+		B:        b.ID,
+		VersionA: a.Version,
+		VersionB: b.Version,
+		Keep:     a.ID,
+		Picks:    make(map[string]int),
+		Errors:   make(FormErrors),
+	}
+	for _, choice := range models.MergeChoices(a, b) {
+		form.Picks[choice.Field] = a.ID
+		if choice.A == "" {
+			form.Picks[choice.Field] = b.ID
+		}
+	}
+	return form
+}
+
+func MergeFormFromRequest(r *http.Request) *MergeForm {
+	r.ParseForm()
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(r.PostForm.Get(key))
+		return n
+	}
+	form := &MergeForm{
+		A:        atoi(MergeFormA),
+		B:        atoi(MergeFormB),
+		VersionA: atoi(MergeFormVersionA),
+		VersionB: atoi(MergeFormVersionB),
+		Keep:     atoi(MergeFormKeep),
+		Picks:    make(map[string]int),
+		Errors:   make(FormErrors),
+	}
+	for key := range r.PostForm {
+		if field, ok := strings.CutPrefix(key, MergeFormPick); ok {
+			form.Picks[field] = atoi(key)
+		}
+	}
+	return form
+}
+
+func (f *MergeForm) Valid() bool {
+	if f.A <= 0 || f.B <= 0 || f.A == f.B {
+		f.Errors.Set(MergeFormKeep, "pick two different contacts")
+	}
+	if f.Keep != f.A && f.Keep != f.B {
+		f.Errors.Set(MergeFormKeep, "pick the contact to keep")
+	}
+	for _, id := range f.Picks {
+		if id != f.A && id != f.B {
+			f.Errors.Set(MergeFormKeep, "pick every field from one of the contacts")
+		}
+	}
+	return len(f.Errors) == 0
+}
+
+// Other is the contact merged into the one kept
+func (f *MergeForm) Other() int {
+	if f.Keep == f.A {
+		return f.B
+	}
+	return f.A
+}
+
+// FromOther are the fields taken from the contact that isn't kept
+func (f *MergeForm) FromOther() []string {
+	var fields []string
+	for field, id := range f.Picks {
+		if id == f.Other() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Picked reports whether field is taken from the contact id
+func (f *MergeForm) Picked(field string, id int) bool {
+	return f.Picks[field] == id
+}
@@ -8,6 +8,23 @@ import (
 type Pagination struct {
 	CurrentPage, TotalPage int
 	URL                    *url.URL
+	// where "Load More" goes on from, empty when there is nothing more
+	Cursor string
+}
+
+// HasMore reports whether there are contacts after the ones shown
+func (p *Pagination) HasMore() bool {
+	return p.Cursor != ""
+}
+
+// More is the page after the contacts shown. unlike Next it follows the
+// last row shown rather than counting rows, so rows added or deleted in the
+// meantime aren't skipped or shown twice.
+func (p *Pagination) More() string {
+	return p.with(func(query url.Values) {
+		query.Del("page")
+		query.Set("cursor", p.Cursor)
+	})
 }
 
 func (p *Pagination) HasNext() bool {
@@ -33,10 +50,19 @@ func (p *Pagination) Page(page int) string {
 	if page > p.TotalPage {
 		page = p.TotalPage
 	}
-	query := p.URL.Query()
-	query.Set("page", fmt.Sprintf("%d", page))
-	p.URL.RawQuery = query.Encode()
-	return p.URL.String()
+	return p.with(func(query url.Values) {
+		query.Del("cursor")
+		query.Set("page", fmt.Sprintf("%d", page))
+	})
+}
+
+// with is the URL of the list with its query changed by change
+func (p *Pagination) with(change func(url.Values)) string {
+	u := *p.URL
+	query := u.Query()
+	change(query)
+	u.RawQuery = query.Encode()
+	return u.String()
 }
 
 // create a pagiantion struct for paginated views
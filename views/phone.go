@@ -0,0 +1,33 @@
+package views
+
+import (
+	"context"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+type phoneRegionKey struct{}
+
+// attaches the region of the address book to ctx so templates format phone
+// numbers of that region nationally
+func WithPhoneRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, phoneRegionKey{}, region)
+}
+
+func PhoneRegion(ctx context.Context) string {
+	region, _ := ctx.Value(phoneRegionKey{}).(string)
+	return region
+}
+
+// formatPhone is how a phone number is shown, see models.Phone.Display
+func formatPhone(ctx context.Context, p models.Phone) string {
+	return p.Display(PhoneRegion(ctx))
+}
+
+// primaryPhone is the first listed phone number, formatted
+func primaryPhone(ctx context.Context, c models.Contact) string {
+	if len(c.Phones) == 0 {
+		return ""
+	}
+	return formatPhone(ctx, c.Phones[0])
+}
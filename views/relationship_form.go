@@ -0,0 +1,43 @@
+package views
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+const (
+	RelationshipFormType    = "relation_type"
+	RelationshipFormRelated = "related_id"
+	RelationshipFormMutual  = "mutual"
+	RelationshipFormSearch  = "q"
+)
+
+type RelationshipForm struct {
+	Type      string
+	RelatedID int
+	Mutual    bool
+	Errors    FormErrors
+}
+
+func RelationshipFormFromRequest(r *http.Request) *RelationshipForm {
+	r.ParseForm()
+	relatedID, _ := strconv.Atoi(r.PostForm.Get(RelationshipFormRelated))
+	return &RelationshipForm{
+		Type:      r.PostForm.Get(RelationshipFormType),
+		RelatedID: relatedID,
+		Mutual:    r.PostForm.Get(RelationshipFormMutual) != "",
+		Errors:    make(FormErrors),
+	}
+}
+
+func (f *RelationshipForm) Valid() bool {
+	if _, ok := models.LookupRelationType(f.Type); !ok {
+		f.Errors.Set(RelationshipFormType, "unknown relationship")
+	}
+	if f.RelatedID <= 0 {
+		f.Errors.Set(RelationshipFormRelated, "pick a contact")
+	}
+	return len(f.Errors) == 0
+}
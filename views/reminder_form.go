@@ -0,0 +1,54 @@
+package views
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+const (
+	ReminderFormDue  = "due"
+	ReminderFormNote = "note"
+)
+
+const maxReminderNoteLength = 500
+
+type ReminderForm struct {
+	Due    string
+	Note   string
+	Errors FormErrors
+}
+
+func NewReminderForm() *ReminderForm {
+	return &ReminderForm{Errors: make(FormErrors)}
+}
+
+func ReminderFormFromRequest(r *http.Request) *ReminderForm {
+	r.ParseForm()
+	return &ReminderForm{
+		Due:    r.PostForm.Get(ReminderFormDue),
+		Note:   strings.TrimSpace(r.PostForm.Get(ReminderFormNote)),
+		Errors: make(FormErrors),
+	}
+}
+
+func (f *ReminderForm) Valid() bool {
+	if _, err := time.Parse(time.DateOnly, f.Due); err != nil {
+		f.Errors.Set(ReminderFormDue, "must be a date like 2024-12-31")
+	}
+	if f.Note == "" {
+		f.Errors.Set(ReminderFormNote, "must not be empty")
+	}
+	if len(f.Note) > maxReminderNoteLength {
+		f.Errors.Set(ReminderFormNote, fmt.Sprintf("must be at most %d characters", maxReminderNoteLength))
+	}
+	return len(f.Errors) == 0
+}
+
+func (f *ReminderForm) ToReminder() models.Reminder {
+	due, _ := time.Parse(time.DateOnly, f.Due)
+	return models.Reminder{Due: due, Note: f.Note}
+}
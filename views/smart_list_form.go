@@ -0,0 +1,64 @@
+package views
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+const (
+	SmartListFormName = "name"
+	// the search being saved, named like the parameters of the contact list
+	SmartListFormQuery     = "q"
+	SmartListFormTag       = "tag"
+	SmartListFormUntouched = "untouched"
+	// the smart list of the bulk actions, which act on all its contacts
+	SmartListFormList = "list"
+)
+
+const maxSmartListNameLength = 100
+
+type SmartListForm struct {
+	Name      string
+	Query     string
+	Tag       string
+	Untouched int
+	Errors    FormErrors
+}
+
+func SmartListFormFromRequest(r *http.Request) *SmartListForm {
+	r.ParseForm()
+	untouched, _ := strconv.Atoi(r.PostForm.Get(SmartListFormUntouched))
+	return &SmartListForm{
+		Name:      strings.TrimSpace(r.PostForm.Get(SmartListFormName)),
+		Query:     strings.TrimSpace(r.PostForm.Get(SmartListFormQuery)),
+		Tag:       models.NormalizeTag(r.PostForm.Get(SmartListFormTag)),
+		Untouched: max(untouched, 0),
+		Errors:    make(FormErrors),
+	}
+}
+
+func (f *SmartListForm) Valid() bool {
+	if f.Name == "" {
+		f.Errors.Set(SmartListFormName, "must not be empty")
+	}
+	if len(f.Name) > maxSmartListNameLength {
+		f.Errors.Set(SmartListFormName, fmt.Sprintf("must be at most %d characters", maxSmartListNameLength))
+	}
+	if f.Query == "" && f.Tag == "" && f.Untouched == 0 {
+		f.Errors.Set(SmartListFormName, "search for something to save first")
+	}
+	return len(f.Errors) == 0
+}
+
+func (f *SmartListForm) ToSmartList() models.SmartList {
+	return models.SmartList{
+		Name:      f.Name,
+		Query:     f.Query,
+		Tag:       f.Tag,
+		Untouched: f.Untouched,
+	}
+}
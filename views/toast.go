@@ -0,0 +1,25 @@
+package views
+
+import (
+	"context"
+	"fmt"
+)
+
+type undoKey struct{}
+
+// attaches the ids of just deleted contacts so the page offers an undo
+func WithUndo(ctx context.Context, ids []int) context.Context {
+	return context.WithValue(ctx, undoKey{}, ids)
+}
+
+func UndoIDs(ctx context.Context) []int {
+	ids, _ := ctx.Value(undoKey{}).([]int)
+	return ids
+}
+
+func deletedMessage(count int) string {
+	if count == 1 {
+		return "Contact moved to trash."
+	}
+	return fmt.Sprintf("%d contacts moved to trash.", count)
+}
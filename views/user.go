@@ -0,0 +1,27 @@
+package views
+
+import (
+	"context"
+
+	"github.com/rezbow/contact-app/models"
+)
+
+type userKey struct{}
+
+// attaches the logged in user to ctx for the navigation bar
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// nil when nobody is logged in
+func CurrentUser(ctx context.Context) *models.User {
+	user, _ := ctx.Value(userKey{}).(*models.User)
+	return user
+}
+
+func displayName(user *models.User) string {
+	if user.Name != "" {
+		return user.Name
+	}
+	return user.Email
+}